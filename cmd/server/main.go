@@ -5,9 +5,15 @@ import (
 	"go-api-tester/internal/database"
 	"go-api-tester/internal/server"
 	"log"
+	"os"
 )
 
 func main() {
+	// 命令行模式: go-api-tester run ... (不启动 Web 服务与托盘)
+	if len(os.Args) > 1 && os.Args[1] == "run" {
+		os.Exit(runCommand(os.Args[2:]))
+	}
+
 	// 1. 初始化数据库
 	if err := database.InitDB(); err != nil {
 		log.Fatalf("数据库初始化失败: %v", err)
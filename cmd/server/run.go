@@ -0,0 +1,265 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go-api-tester/internal/api"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/runner"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// 退出码
+const (
+	exitOK     = 0 // 全部通过
+	exitFailed = 1 // 存在失败的请求或断言
+	exitError  = 2 // 参数错误、数据加载失败等
+)
+
+// runCommand 实现 run 子命令: 直接运行分组或单个请求并输出报告，适用于 CI
+func runCommand(args []string) int {
+	fs := flag.NewFlagSet("run", flag.ContinueOnError)
+	fs.SetOutput(os.Stderr)
+	dbPath := fs.String("db", "", "SQLite 数据库路径 (默认与 Web 服务相同)")
+	dataFile := fs.String("data", "", "导出的 JSON 数据文件，指定后不读取数据库")
+	collection := fs.String("collection", "", "要运行的分组: ID、名称或路径 (如 payments/v2)")
+	request := fs.String("request", "", "要运行的单个请求: ID 或名称")
+	env := fs.String("env", "", "使用的环境: ID 或名称 (默认使用当前激活的环境)")
+	format := fs.String("format", "text", "输出格式: text | json | junit")
+	output := fs.String("output", "", "报告输出文件 (默认输出到标准输出)")
+	iterations := fs.Int("iterations", 1, "重复运行的轮数")
+	delay := fs.Int("delay", 0, "相邻请求之间的等待时间 (毫秒)")
+	bail := fs.Bool("bail", false, "首个失败后立即停止")
+	fs.Usage = func() {
+		fmt.Fprintln(os.Stderr, "用法: go-api-tester run (-collection <分组> | -request <请求>) [选项]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return exitError
+	}
+
+	if (*collection == "") == (*request == "") {
+		fmt.Fprintln(os.Stderr, "必须且只能指定 -collection 或 -request 之一")
+		fs.Usage()
+		return exitError
+	}
+
+	var write func(io.Writer, *runner.Report) error
+	switch *format {
+	case "text":
+		write = runner.WriteText
+	case "json":
+		write = runner.WriteJSON
+	case "junit":
+		write = runner.WriteJUnit
+	default:
+		fmt.Fprintf(os.Stderr, "不支持的输出格式: %s\n", *format)
+		return exitError
+	}
+
+	// 1. 加载数据
+	ids, err := openRunData(*dbPath, *dataFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "加载数据失败: %v\n", err)
+		return exitError
+	}
+	defer database.Close()
+
+	// 2. 解析运行目标
+	opts := runner.Options{
+		StopOnFailure: *bail,
+		DelayMs:       *delay,
+		Iterations:    *iterations,
+	}
+	if *env != "" {
+		if opts.EnvironmentID, err = ids.findEnvironment(*env); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+	}
+
+	var report *runner.Report
+	if *collection != "" {
+		var id int64
+		if id, err = ids.findCollection(*collection); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		report, err = runner.RunCollection(id, opts)
+	} else {
+		var id int64
+		if id, err = ids.findRequest(*request); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return exitError
+		}
+		report, err = runner.RunRequest(id, opts)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "运行失败: %v\n", err)
+		return exitError
+	}
+
+	// 3. 输出报告
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "无法创建报告文件: %v\n", err)
+			return exitError
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := write(out, report); err != nil {
+		fmt.Fprintf(os.Stderr, "写入报告失败: %v\n", err)
+		return exitError
+	}
+
+	if !report.Success() {
+		return exitFailed
+	}
+	return exitOK
+}
+
+// runData 记录导出文件中的旧 ID 到内存库新 ID 的映射 (直接使用数据库时为 nil)
+type runData struct {
+	imported *api.ImportResult
+}
+
+// openRunData 打开数据库；指定导出文件时将其还原到内存数据库中
+func openRunData(dbPath, dataFile string) (*runData, error) {
+	if dataFile != "" {
+		raw, err := os.ReadFile(dataFile)
+		if err != nil {
+			return nil, err
+		}
+		var dump api.DataDump
+		if err := json.Unmarshal(raw, &dump); err != nil {
+			return nil, fmt.Errorf("无效的数据文件: %v", err)
+		}
+		if err := database.Open(":memory:"); err != nil {
+			return nil, err
+		}
+		return &runData{imported: api.ImportDump(&dump, true)}, nil
+	}
+
+	if dbPath == "" {
+		var err error
+		if dbPath, err = database.DefaultPath(); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, err
+	}
+	return &runData{}, database.Open(dbPath)
+}
+
+// mapID 将用户给出的 ID 映射为当前数据库中的 ID
+func (d *runData) mapID(id int64, m func(*api.ImportResult) map[int64]int64) (int64, bool) {
+	if d.imported == nil {
+		return id, true
+	}
+	newID, ok := m(d.imported)[id]
+	return newID, ok
+}
+
+// findCollection 按 ID、完整路径或名称查找分组
+func (d *runData) findCollection(ref string) (int64, error) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		if newID, ok := d.mapID(id, func(r *api.ImportResult) map[int64]int64 { return r.CollectionIDs }); ok {
+			return newID, nil
+		}
+		return 0, fmt.Errorf("分组不存在: %s", ref)
+	}
+
+	roots, err := database.GetAllCollections()
+	if err != nil {
+		return 0, err
+	}
+	var byPath, byName []int64
+	var walk func(nodes []*database.Collection, prefix string)
+	walk = func(nodes []*database.Collection, prefix string) {
+		for _, c := range nodes {
+			path := c.Name
+			if prefix != "" {
+				path = prefix + "/" + c.Name
+			}
+			if path == ref {
+				byPath = append(byPath, c.ID)
+			}
+			if c.Name == ref {
+				byName = append(byName, c.ID)
+			}
+			walk(c.Children, path)
+		}
+	}
+	walk(roots, "")
+
+	if len(byPath) == 1 {
+		return byPath[0], nil
+	}
+	return pickUnique("分组", ref, byName)
+}
+
+// findRequest 按 ID 或名称查找请求
+func (d *runData) findRequest(ref string) (int64, error) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		if newID, ok := d.mapID(id, func(r *api.ImportResult) map[int64]int64 { return r.RequestIDs }); ok {
+			return newID, nil
+		}
+		return 0, fmt.Errorf("请求不存在: %s", ref)
+	}
+
+	reqs, err := database.GetAllRequests()
+	if err != nil {
+		return 0, err
+	}
+	var matched []int64
+	for _, r := range reqs {
+		if r.Name == ref {
+			matched = append(matched, r.ID)
+		}
+	}
+	return pickUnique("请求", ref, matched)
+}
+
+// findEnvironment 按 ID 或名称查找环境
+func (d *runData) findEnvironment(ref string) (int64, error) {
+	if id, err := strconv.ParseInt(ref, 10, 64); err == nil {
+		if newID, ok := d.mapID(id, func(r *api.ImportResult) map[int64]int64 { return r.EnvironmentIDs }); ok {
+			return newID, nil
+		}
+		return 0, fmt.Errorf("环境不存在: %s", ref)
+	}
+
+	envs, err := database.GetAllEnvironments()
+	if err != nil {
+		return 0, err
+	}
+	var matched []int64
+	for _, e := range envs {
+		if e.Name == ref {
+			matched = append(matched, e.ID)
+		}
+	}
+	return pickUnique("环境", ref, matched)
+}
+
+func pickUnique(kind, ref string, ids []int64) (int64, error) {
+	switch len(ids) {
+	case 0:
+		return 0, fmt.Errorf("%s不存在: %s", kind, ref)
+	case 1:
+		return ids[0], nil
+	}
+	list := make([]string, len(ids))
+	for i, id := range ids {
+		list[i] = strconv.FormatInt(id, 10)
+	}
+	return 0, fmt.Errorf("%s名称 %q 不唯一，请改用 ID (%s)", kind, ref, strings.Join(list, ", "))
+}
@@ -3,16 +3,15 @@
 package main
 
 import (
+	"log"
 	"os/exec"
 	"runtime"
-	"log"
-	"fmt"
 )
 
 func runTray(url string) {
 	// 非 Windows 系统直接打开浏览器并阻塞主线程
 	openBrowser(url)
-	
+
 	// 阻塞主线程，防止程序退出
 	select {}
 }
@@ -28,4 +27,4 @@ func openBrowser(url string) {
 	if err != nil {
 		log.Printf("Warning: Failed to open browser: %v", err)
 	}
-}
\ No newline at end of file
+}
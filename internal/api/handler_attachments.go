@@ -0,0 +1,131 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"go-api-tester/internal/database"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// maxAttachmentSize 单个附件的大小上限
+const maxAttachmentSize = 200 << 20
+
+// HandleListAttachments 获取附件列表
+func HandleListAttachments(w http.ResponseWriter, r *http.Request) {
+	list, err := database.GetAllAttachments()
+	if err != nil {
+		http.Error(w, "Failed to fetch attachments: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		list = []*database.Attachment{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleUploadAttachment 上传附件
+// 支持两种方式:
+//   - multipart/form-data: 取第一个文件字段，文件名与 Content-Type 取自该分段
+//   - 其他: 请求体即文件内容，文件名由 ?name= 指定，Content-Type 取自请求头
+//
+// 返回的 ref (attachment:<id>) 可直接填入 form-data 文件字段或 binary_path
+func HandleUploadAttachment(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxAttachmentSize)
+
+	var (
+		att *database.Attachment
+		err error
+	)
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		mr, mErr := r.MultipartReader()
+		if mErr != nil {
+			http.Error(w, "Invalid multipart body", http.StatusBadRequest)
+			return
+		}
+		for {
+			part, pErr := mr.NextPart()
+			if pErr == io.EOF {
+				http.Error(w, "No file field found", http.StatusBadRequest)
+				return
+			}
+			if pErr != nil {
+				http.Error(w, "Invalid multipart body: "+pErr.Error(), http.StatusBadRequest)
+				return
+			}
+			if part.FileName() == "" {
+				continue
+			}
+			att, err = database.CreateAttachment(part.FileName(), part.Header.Get("Content-Type"), part)
+			break
+		}
+	} else {
+		name := strings.TrimSpace(r.URL.Query().Get("name"))
+		if name == "" {
+			http.Error(w, "Query parameter name is required", http.StatusBadRequest)
+			return
+		}
+		att, err = database.CreateAttachment(name, r.Header.Get("Content-Type"), r.Body)
+	}
+
+	if err != nil {
+		http.Error(w, "Failed to save attachment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"attachment": att,
+		"ref":        "attachment:" + strconv.FormatInt(att.ID, 10),
+		"message":    "Attachment uploaded",
+	})
+}
+
+// HandleDownloadAttachment 下载附件内容
+func HandleDownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	f, att, err := database.OpenAttachment(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Attachment not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to open attachment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+
+	if att.ContentType != "" {
+		w.Header().Set("Content-Type", att.ContentType)
+	}
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": att.Name}))
+	http.ServeContent(w, r, att.Name, att.CreatedAt, f)
+}
+
+// HandleDeleteAttachment 删除附件
+func HandleDeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DeleteAttachment(id); err != nil {
+		http.Error(w, "Failed to delete attachment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Attachment deleted"}`))
+}
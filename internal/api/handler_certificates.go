@@ -0,0 +1,142 @@
+package api
+
+import (
+	"encoding/json"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/proxy"
+	"net/http"
+	"strconv"
+)
+
+// certificateView 列表返回的证书信息，不包含私钥与口令
+type certificateView struct {
+	ID            int64  `json:"id"`
+	Name          string `json:"name"`
+	HostPattern   string `json:"host_pattern"`
+	HasClientCert bool   `json:"has_client_cert"`
+	HasPassphrase bool   `json:"has_passphrase"`
+	HasCACert     bool   `json:"has_ca_cert"`
+	ClientCert    string `json:"client_cert,omitempty"`
+	CACert        string `json:"ca_cert,omitempty"`
+	Enabled       bool   `json:"enabled"`
+	CreatedAt     string `json:"created_at"`
+}
+
+func toCertificateView(c *database.Certificate) certificateView {
+	return certificateView{
+		ID:            c.ID,
+		Name:          c.Name,
+		HostPattern:   c.HostPattern,
+		HasClientCert: c.ClientCert != "",
+		HasPassphrase: c.Passphrase != "",
+		HasCACert:     c.CACert != "",
+		ClientCert:    c.ClientCert,
+		CACert:        c.CACert,
+		Enabled:       c.Enabled,
+		CreatedAt:     c.CreatedAt.Format("2006-01-02 15:04:05"),
+	}
+}
+
+// HandleListCertificates 获取证书列表
+func HandleListCertificates(w http.ResponseWriter, r *http.Request) {
+	list, err := database.GetAllCertificates()
+	if err != nil {
+		http.Error(w, "Failed to fetch certificates: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	views := []certificateView{}
+	for _, c := range list {
+		views = append(views, toCertificateView(c))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(views)
+}
+
+// HandleCreateCertificate 新增证书，保存前校验证书与私钥能否加载
+func HandleCreateCertificate(w http.ResponseWriter, r *http.Request) {
+	var c database.Certificate
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if c.Name == "" || c.HostPattern == "" {
+		http.Error(w, "Name and host_pattern are required", http.StatusBadRequest)
+		return
+	}
+	if err := proxy.ValidateCertificate(&c); err != nil {
+		http.Error(w, "Invalid certificate: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	id, err := database.CreateCertificate(&c)
+	if err != nil {
+		http.Error(w, "Failed to create certificate: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "message": "Certificate created"})
+}
+
+// HandleUpdateCertificate 更新证书
+// 私钥与口令留空时保留原值，便于只修改名称或主机模式
+func HandleUpdateCertificate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	old, err := database.GetCertificate(id)
+	if err != nil {
+		http.Error(w, "Certificate not found", http.StatusNotFound)
+		return
+	}
+
+	var c database.Certificate
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if c.Name == "" || c.HostPattern == "" {
+		http.Error(w, "Name and host_pattern are required", http.StatusBadRequest)
+		return
+	}
+	c.ID = id
+	if c.ClientKey == "" && c.ClientCert != "" {
+		c.ClientKey = old.ClientKey
+		if c.Passphrase == "" {
+			c.Passphrase = old.Passphrase
+		}
+	}
+	if err := proxy.ValidateCertificate(&c); err != nil {
+		http.Error(w, "Invalid certificate: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.UpdateCertificate(&c); err != nil {
+		http.Error(w, "Failed to update certificate: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Certificate updated"}`))
+}
+
+// HandleDeleteCertificate 删除证书
+func HandleDeleteCertificate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DeleteCertificate(id); err != nil {
+		http.Error(w, "Failed to delete certificate: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Certificate deleted"}`))
+}
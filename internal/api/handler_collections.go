@@ -21,8 +21,11 @@ func HandleGetCollections(w http.ResponseWriter, r *http.Request) {
 
 // CreateCollectionRequest 定义创建请求的 Body
 type CreateCollectionRequest struct {
-	Name     string `json:"name"`
-	ParentID int64  `json:"parent_id"`
+	Name      string               `json:"name"`
+	ParentID  int64                `json:"parent_id"`
+	Variables []database.KeyValue  `json:"variables"`
+	Auth      *database.AuthConfig `json:"auth,omitempty"`
+	Scripts   *database.Scripts    `json:"scripts,omitempty"`
 }
 
 // HandleCreateCollection 创建分组
@@ -37,12 +40,34 @@ func HandleCreateCollection(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Name is required", http.StatusBadRequest)
 		return
 	}
+	if err := database.ValidateCollectionParent(0, req.ParentID); err != nil {
+		http.Error(w, "Invalid parent: "+err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	id, err := database.CreateCollection(req.Name, req.ParentID)
 	if err != nil {
 		http.Error(w, "Failed to create collection: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	if len(req.Variables) > 0 {
+		if err := database.SetCollectionVariables(id, req.Variables); err != nil {
+			http.Error(w, "Failed to save collection variables: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.Auth != nil {
+		if err := database.SetCollectionAuth(id, req.Auth); err != nil {
+			http.Error(w, "Failed to save collection auth: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if req.Scripts != nil {
+		if err := database.SetCollectionScripts(id, req.Scripts); err != nil {
+			http.Error(w, "Failed to save collection scripts: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
@@ -52,6 +77,39 @@ func HandleCreateCollection(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// HandleUpdateCollection 更新分组 (名称、父节点、变量、认证、脚本)
+func HandleUpdateCollection(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var col database.Collection
+	if err := json.NewDecoder(r.Body).Decode(&col); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if col.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	col.ID = id
+	if err := database.ValidateCollectionParent(id, col.ParentID); err != nil {
+		http.Error(w, "Invalid parent: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.UpdateCollection(&col); err != nil {
+		http.Error(w, "Failed to update collection: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Collection updated"}`))
+}
+
 // HandleDeleteCollection 删除分组
 // 路径参数通常需要通过 r.PathValue (Go 1.22+) 获取
 func HandleDeleteCollection(w http.ResponseWriter, r *http.Request) {
@@ -70,4 +128,4 @@ func HandleDeleteCollection(w http.ResponseWriter, r *http.Request) {
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"message": "Collection deleted"}`))
-}
\ No newline at end of file
+}
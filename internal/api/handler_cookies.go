@@ -0,0 +1,121 @@
+package api
+
+import (
+	"encoding/json"
+	"go-api-tester/internal/database"
+	"net/http"
+	"strconv"
+)
+
+// HandleListCookies 查询 Cookie，支持 ?environment_id= 与 ?domain= 过滤
+func HandleListCookies(w http.ResponseWriter, r *http.Request) {
+	var envID *int64
+	if s := r.URL.Query().Get("environment_id"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid environment_id", http.StatusBadRequest)
+			return
+		}
+		envID = &id
+	}
+
+	cookies, err := database.GetCookies(envID, r.URL.Query().Get("domain"))
+	if err != nil {
+		http.Error(w, "Failed to fetch cookies: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if cookies == nil {
+		cookies = []*database.Cookie{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cookies)
+}
+
+// HandleCreateCookie 新增 Cookie (同环境、域名、路径、名称的 Cookie 会被覆盖)
+func HandleCreateCookie(w http.ResponseWriter, r *http.Request) {
+	var c database.Cookie
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if c.Domain == "" || c.Name == "" {
+		http.Error(w, "Domain and name are required", http.StatusBadRequest)
+		return
+	}
+
+	id, err := database.SaveCookie(&c)
+	if err != nil {
+		http.Error(w, "Failed to save cookie: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "message": "Cookie saved"})
+}
+
+// HandleUpdateCookie 更新 Cookie
+func HandleUpdateCookie(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var c database.Cookie
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if c.Domain == "" || c.Name == "" {
+		http.Error(w, "Domain and name are required", http.StatusBadRequest)
+		return
+	}
+	c.ID = id
+
+	if err := database.UpdateCookie(&c); err != nil {
+		http.Error(w, "Failed to update cookie: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Cookie updated"}`))
+}
+
+// HandleDeleteCookie 删除单个 Cookie
+func HandleDeleteCookie(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DeleteCookie(id); err != nil {
+		http.Error(w, "Failed to delete cookie: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Cookie deleted"}`))
+}
+
+// HandleClearCookies 清空某环境 (?environment_id=，默认 0) 的 Cookie，可用 ?domain= 限定域名
+func HandleClearCookies(w http.ResponseWriter, r *http.Request) {
+	var envID int64
+	if s := r.URL.Query().Get("environment_id"); s != "" {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid environment_id", http.StatusBadRequest)
+			return
+		}
+		envID = id
+	}
+
+	if err := database.ClearCookies(envID, r.URL.Query().Get("domain")); err != nil {
+		http.Error(w, "Failed to clear cookies: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Cookies cleared"}`))
+}
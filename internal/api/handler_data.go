@@ -10,11 +10,13 @@ import (
 
 // DataDump 定义导出文件的结构
 type DataDump struct {
-	Version     string                     `json:"version"`
-	ExportedAt  time.Time                  `json:"exported_at"`
-	Collections []*database.Collection     `json:"collections"`
-	Requests    []*database.Request        `json:"requests"`
-	MockRules   []*database.MockRule       `json:"mock_rules"`
+	Version      string                  `json:"version"`
+	ExportedAt   time.Time               `json:"exported_at"`
+	Collections  []*database.Collection  `json:"collections"`
+	Requests     []*database.Request     `json:"requests"`
+	MockRules    []*database.MockRule    `json:"mock_rules"`
+	Environments []*database.Environment `json:"environments,omitempty"`
+	Globals      []database.KeyValue     `json:"globals,omitempty"`
 }
 
 // HandleExportData 导出所有数据
@@ -34,13 +36,25 @@ func HandleExportData(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to fetch mocks", 500)
 		return
 	}
+	envs, err := database.GetAllEnvironments()
+	if err != nil {
+		http.Error(w, "Failed to fetch environments", 500)
+		return
+	}
+	globals, err := database.GetGlobalVariables()
+	if err != nil {
+		http.Error(w, "Failed to fetch globals", 500)
+		return
+	}
 
 	dump := DataDump{
-		Version:     "1.0",
-		ExportedAt:  time.Now(),
-		Collections: cols,
-		Requests:    reqs,
-		MockRules:   mocks,
+		Version:      "1.0",
+		ExportedAt:   time.Now(),
+		Collections:  cols,
+		Requests:     reqs,
+		MockRules:    mocks,
+		Environments: envs,
+		Globals:      globals,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -56,16 +70,53 @@ func HandleImportData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	result := ImportDump(&dump, false)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":      "Import successful",
+		"collections":  result.Collections,
+		"requests":     result.Requests,
+		"mocks":        result.Mocks,
+		"environments": result.Environments,
+	})
+}
+
+// ImportResult 导入统计及旧 ID 到新 ID 的映射
+type ImportResult struct {
+	Collections  int
+	Requests     int
+	Mocks        int
+	Environments int
+
+	CollectionIDs  map[int64]int64
+	RequestIDs     map[int64]int64
+	EnvironmentIDs map[int64]int64
+}
+
+// ImportDump 将导出数据写入数据库
+// restore 为 true 表示还原到空库 (命令行运行时使用)：保留原分组名称与环境激活状态；
+// 否则作为合并导入，分组名追加 (Imported) 标记且不改变当前激活的环境
+func ImportDump(dump *DataDump, restore bool) *ImportResult {
+	result := &ImportResult{
+		CollectionIDs:  make(map[int64]int64),
+		RequestIDs:     make(map[int64]int64),
+		EnvironmentIDs: make(map[int64]int64),
+	}
+	nameSuffix, orphanSuffix := " (Imported)", " (Imported-Orphan)"
+	if restore {
+		nameSuffix, orphanSuffix = "", ""
+	}
+
 	// ID 映射表: OldID -> NewID
-	colMap := make(map[int64]int64)
+	colMap := result.CollectionIDs
 
 	// 1. 导入分组
 	// 简单的拓扑排序策略：多次循环，先插入父节点已存在的（或根节点）
 	// 这里的简化版：假设导出是按 ID 排序的，通常父 ID < 子 ID。
 	// 如果不是，可能需要多轮尝试。这里为了稳健，采用多轮扫描。
 	pendingCols := dump.Collections
-	insertedCount := 0
-	
+
 	// 最多循环 10 次处理层级嵌套，防止死循环
 	for i := 0; i < 10 && len(pendingCols) > 0; i++ {
 		var nextPending []*database.Collection
@@ -83,10 +134,19 @@ func HandleImportData(w http.ResponseWriter, r *http.Request) {
 
 			if canInsert {
 				// 插入并记录映射
-				newID, err := database.CreateCollection(col.Name+" (Imported)", newParentID)
+				newID, err := database.CreateCollection(col.Name+nameSuffix, newParentID)
 				if err == nil {
 					colMap[col.ID] = newID
-					insertedCount++
+					result.Collections++
+					if len(col.Variables) > 0 {
+						database.SetCollectionVariables(newID, col.Variables)
+					}
+					if col.Auth != nil {
+						database.SetCollectionAuth(newID, col.Auth)
+					}
+					if col.Scripts != nil {
+						database.SetCollectionScripts(newID, col.Scripts)
+					}
 				}
 			} else {
 				nextPending = append(nextPending, col)
@@ -95,9 +155,18 @@ func HandleImportData(w http.ResponseWriter, r *http.Request) {
 		// 如果没有进展，说明有孤儿节点或循环依赖，强制作为根节点插入剩余的
 		if len(pendingCols) == len(nextPending) {
 			for _, col := range nextPending {
-				newID, _ := database.CreateCollection(col.Name+" (Imported-Orphan)", 0)
+				newID, _ := database.CreateCollection(col.Name+orphanSuffix, 0)
 				colMap[col.ID] = newID
-				insertedCount++
+				result.Collections++
+				if len(col.Variables) > 0 {
+					database.SetCollectionVariables(newID, col.Variables)
+				}
+				if col.Auth != nil {
+					database.SetCollectionAuth(newID, col.Auth)
+				}
+				if col.Scripts != nil {
+					database.SetCollectionScripts(newID, col.Scripts)
+				}
 			}
 			break
 		}
@@ -105,7 +174,6 @@ func HandleImportData(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// 2. 导入请求
-	reqCount := 0
 	for _, req := range dump.Requests {
 		// 映射 Collection ID
 		newColID := int64(0)
@@ -116,26 +184,50 @@ func HandleImportData(w http.ResponseWriter, r *http.Request) {
 		}
 		req.CollectionID = newColID
 		// 重置 ID 让 DB 生成
-		req.ID = 0 
-		if _, err := database.CreateRequest(req); err == nil {
-			reqCount++
+		oldID := req.ID
+		req.ID = 0
+		if newID, err := database.CreateRequest(req); err == nil {
+			result.RequestIDs[oldID] = newID
+			result.Requests++
 		}
 	}
 
 	// 3. 导入 Mock
-	mockCount := 0
 	for _, rule := range dump.MockRules {
 		rule.ID = 0
 		if _, err := database.CreateMockRule(rule); err == nil {
-			mockCount++
+			result.Mocks++
 		}
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
-		"message":     "Import successful",
-		"collections": insertedCount,
-		"requests":    reqCount,
-		"mocks":       mockCount,
-	})
-}
\ No newline at end of file
+	// 4. 导入环境 (合并导入时不覆盖当前激活状态)
+	for _, env := range dump.Environments {
+		oldID := env.ID
+		env.ID = 0
+		if !restore {
+			env.IsActive = false
+		}
+		if newID, err := database.CreateEnvironment(env); err == nil {
+			result.EnvironmentIDs[oldID] = newID
+			result.Environments++
+		}
+	}
+
+	// 5. 合并全局变量 (已存在的同名变量保持不变)
+	if len(dump.Globals) > 0 {
+		if current, err := database.GetGlobalVariables(); err == nil {
+			existing := make(map[string]bool)
+			for _, v := range current {
+				existing[v.Key] = true
+			}
+			for _, v := range dump.Globals {
+				if !existing[v.Key] {
+					current = append(current, v)
+				}
+			}
+			database.SaveGlobalVariables(current)
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,148 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/proxy"
+	"net/http"
+	"strconv"
+)
+
+// HandleListEnvironments 获取环境列表
+func HandleListEnvironments(w http.ResponseWriter, r *http.Request) {
+	envs, err := database.GetAllEnvironments()
+	if err != nil {
+		http.Error(w, "Failed to fetch environments: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if envs == nil {
+		envs = []*database.Environment{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(envs)
+}
+
+// HandleGetEnvironment 获取单个环境
+func HandleGetEnvironment(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	env, err := database.GetEnvironment(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Environment not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch environment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(env)
+}
+
+// HandleCreateEnvironment 创建环境
+func HandleCreateEnvironment(w http.ResponseWriter, r *http.Request) {
+	var env database.Environment
+	if err := json.NewDecoder(r.Body).Decode(&env); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if env.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if env.Proxy != nil {
+		if err := proxy.ValidateProxySettings(*env.Proxy); err != nil {
+			http.Error(w, "Invalid proxy: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	id, err := database.CreateEnvironment(&env)
+	if err != nil {
+		http.Error(w, "Failed to create environment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "message": "Environment created"})
+}
+
+// HandleUpdateEnvironment 更新环境
+func HandleUpdateEnvironment(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var env database.Environment
+	if err := json.NewDecoder(r.Body).Decode(&env); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	env.ID = id
+	if env.Name == "" {
+		http.Error(w, "Name is required", http.StatusBadRequest)
+		return
+	}
+	if env.Proxy != nil {
+		if err := proxy.ValidateProxySettings(*env.Proxy); err != nil {
+			http.Error(w, "Invalid proxy: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if err := database.UpdateEnvironment(&env); err != nil {
+		http.Error(w, "Failed to update environment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Environment updated"}`))
+}
+
+// HandleActivateEnvironment 切换当前激活的环境 (id 为 0 表示不使用环境)
+func HandleActivateEnvironment(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := database.SetActiveEnvironment(id); err != nil {
+		http.Error(w, "Failed to activate environment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Environment activated"}`))
+}
+
+// HandleDeleteEnvironment 删除环境
+func HandleDeleteEnvironment(w http.ResponseWriter, r *http.Request) {
+	idStr := r.PathValue("id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DeleteEnvironment(id); err != nil {
+		http.Error(w, "Failed to delete environment: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Environment deleted"}`))
+}
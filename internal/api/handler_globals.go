@@ -0,0 +1,35 @@
+package api
+
+import (
+	"encoding/json"
+	"go-api-tester/internal/database"
+	"net/http"
+)
+
+// HandleGetGlobals 获取全局变量
+func HandleGetGlobals(w http.ResponseWriter, r *http.Request) {
+	vars, err := database.GetGlobalVariables()
+	if err != nil {
+		http.Error(w, "Failed to fetch globals: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vars)
+}
+
+// HandleSaveGlobals 覆盖保存全局变量
+func HandleSaveGlobals(w http.ResponseWriter, r *http.Request) {
+	var vars []database.KeyValue
+	if err := json.NewDecoder(r.Body).Decode(&vars); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	if err := database.SaveGlobalVariables(vars); err != nil {
+		http.Error(w, "Failed to save globals: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Globals saved"}`))
+}
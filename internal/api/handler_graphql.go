@@ -0,0 +1,93 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"go-api-tester/internal/proxy"
+	"net/http"
+)
+
+// introspectRequest 内省请求: 与发送请求的结构相同 (url、headers、auth、environment_id 等)
+type introspectRequest struct {
+	proxy.ProxyRequest
+	Refresh bool `json:"refresh"` // 忽略缓存重新内省
+}
+
+// HandleIntrospectGraphQL 获取并缓存目标地址的 GraphQL schema，返回可用的操作列表
+func HandleIntrospectGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req introspectRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if req.URL == "" {
+		http.Error(w, "URL is required", http.StatusBadRequest)
+		return
+	}
+
+	info, err := proxy.IntrospectSchema(req.ProxyRequest, req.Refresh)
+	if err != nil {
+		http.Error(w, "Introspection failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleValidateGraphQL 校验 GraphQL 查询 (未缓存 schema 时只检查语法)
+func HandleValidateGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req proxy.ProxyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if req.URL == "" {
+		http.Error(w, "URL is required", http.StatusBadRequest)
+		return
+	}
+
+	result, err := proxy.ValidateGraphQL(req)
+	if err != nil {
+		http.Error(w, "Validation failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// HandleGetGraphQLSchema 获取已缓存的 schema (?url=)
+func HandleGetGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		http.Error(w, "Query parameter url is required", http.StatusBadRequest)
+		return
+	}
+
+	info, err := proxy.CachedSchema(url)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Schema not cached", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to load schema: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(info)
+}
+
+// HandleDeleteGraphQLSchema 清除已缓存的 schema (?url=)
+func HandleDeleteGraphQLSchema(w http.ResponseWriter, r *http.Request) {
+	url := r.URL.Query().Get("url")
+	if url == "" {
+		http.Error(w, "Query parameter url is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := proxy.ClearCachedSchema(url); err != nil {
+		http.Error(w, "Failed to delete schema: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Schema cache cleared"}`))
+}
@@ -1,21 +1,114 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
+	"fmt"
 	"go-api-tester/internal/database"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 )
 
-// HandleGetHistory 获取历史列表
+// HandleGetHistory 获取历史列表 (游标分页)
+// 响应体为历史记录数组，还有更多记录时通过 X-Next-Cursor 响应头返回下一页的游标
+//
+// 查询参数:
+//   - cursor: 上一页返回的 X-Next-Cursor；limit: 每页条数 (默认 100，最大 500)
+//   - method: 请求方法，多个以逗号分隔
+//   - status: 状态码类别，如 2xx,4xx；error 表示请求失败未收到响应
+//   - url: URL 子串 (实际发送的 URL 或模板)；q: 在 URL、请求体与响应体中全文搜索
+//   - from, to: 日期 (YYYY-MM-DD，to 包含当天) 或 RFC3339 时间
+//   - environment_id: 发送时使用的环境
 func HandleGetHistory(w http.ResponseWriter, r *http.Request) {
-	history, err := database.GetHistory()
+	filter, err := parseHistoryFilter(r.URL.Query())
 	if err != nil {
-		http.Error(w, "Failed to fetch history", 500)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
+	page, err := database.ListHistory(filter)
+	if err != nil {
+		http.Error(w, "Failed to fetch history: "+err.Error(), 500)
+		return
+	}
+	if page.NextCursor != 0 {
+		w.Header().Set("X-Next-Cursor", strconv.FormatInt(page.NextCursor, 10))
+	}
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(history)
+	json.NewEncoder(w).Encode(page.Items)
+}
+
+func parseHistoryFilter(q url.Values) (database.HistoryFilter, error) {
+	f := database.HistoryFilter{URL: q.Get("url"), Query: q.Get("q")}
+
+	var err error
+	if v := q.Get("cursor"); v != "" {
+		if f.Cursor, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return f, fmt.Errorf("invalid cursor: %s", v)
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil {
+			return f, fmt.Errorf("invalid limit: %s", v)
+		}
+	}
+	if v := q.Get("environment_id"); v != "" {
+		if f.EnvironmentID, err = strconv.ParseInt(v, 10, 64); err != nil {
+			return f, fmt.Errorf("invalid environment_id: %s", v)
+		}
+	}
+	for _, m := range splitList(q.Get("method")) {
+		f.Methods = append(f.Methods, strings.ToUpper(m))
+	}
+	for _, s := range splitList(q.Get("status")) {
+		s = strings.ToLower(s)
+		if s == "error" {
+			f.StatusClasses = append(f.StatusClasses, 0)
+			continue
+		}
+		if len(s) != 3 || s[0] < '1' || s[0] > '5' || s[1:] != "xx" {
+			return f, fmt.Errorf("invalid status class: %s (expected 1xx-5xx or error)", s)
+		}
+		f.StatusClasses = append(f.StatusClasses, int(s[0]-'0'))
+	}
+	if v := q.Get("from"); v != "" {
+		if f.From, err = parseHistoryTime(v, false); err != nil {
+			return f, err
+		}
+	}
+	if v := q.Get("to"); v != "" {
+		if f.To, err = parseHistoryTime(v, true); err != nil {
+			return f, err
+		}
+	}
+	return f, nil
+}
+
+// parseHistoryTime 解析 RFC3339 时间或本地日期，日期作为结束时间时取次日零点 (包含当天)
+func parseHistoryTime(v string, end bool) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, v); err == nil {
+		return t, nil
+	}
+	t, err := time.ParseInLocation(time.DateOnly, v, time.Local)
+	if err != nil {
+		return t, fmt.Errorf("invalid date: %s (expected YYYY-MM-DD or RFC3339)", v)
+	}
+	if end {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, nil
+}
+
+func splitList(v string) []string {
+	var out []string
+	for _, s := range strings.Split(v, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			out = append(out, s)
+		}
+	}
+	return out
 }
 
 // HandleCreateHistory 创建历史
@@ -67,3 +160,45 @@ func HandleDeleteHistory(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"message": "History deleted successfully"}`))
 }
+
+// HandleGetHistoryItem 获取单条历史记录 (包含保存的完整响应)
+func HandleGetHistoryItem(w http.ResponseWriter, r *http.Request) {
+	item, ok := loadHistoryItem(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// HandleGetHistoryResponse 只获取历史记录保存的响应，未保存响应时返回 404
+func HandleGetHistoryResponse(w http.ResponseWriter, r *http.Request) {
+	item, ok := loadHistoryItem(w, r)
+	if !ok {
+		return
+	}
+	if item.Response == nil {
+		http.Error(w, "No response recorded", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item.Response)
+}
+
+func loadHistoryItem(w http.ResponseWriter, r *http.Request) (*database.HistoryItem, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return nil, false
+	}
+	item, err := database.GetHistoryItem(id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "History not found", http.StatusNotFound)
+			return nil, false
+		}
+		http.Error(w, "Failed to fetch history: "+err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return item, true
+}
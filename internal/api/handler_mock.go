@@ -1,8 +1,10 @@
 package api
 
 import (
+	"database/sql"
 	"encoding/json"
 	"go-api-tester/internal/database"
+	"go-api-tester/internal/mock"
 	"net/http"
 	"strconv"
 )
@@ -30,6 +32,10 @@ func HandleCreateMockRule(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Path pattern is required", http.StatusBadRequest)
 		return
 	}
+	if err := mock.ValidateRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	if rule.StatusCode == 0 {
 		rule.StatusCode = 200
 	}
@@ -61,10 +67,16 @@ func HandleUpdateMockRule(w http.ResponseWriter, r *http.Request) {
 	}
 	rule.ID = id
 
+	if err := mock.ValidateRule(&rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	if err := database.UpdateMockRule(&rule); err != nil {
 		http.Error(w, "Failed to update rule: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	mock.ForgetRule(id)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"message": "Mock rule updated"}`))
@@ -83,7 +95,73 @@ func HandleDeleteMockRule(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Failed to delete rule: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
+	mock.ForgetRule(id)
 
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte(`{"message": "Mock rule deleted"}`))
-}
\ No newline at end of file
+}
+
+// HandleListMockScenarios 列出 Mock 场景及其当前状态
+func HandleListMockScenarios(w http.ResponseWriter, r *http.Request) {
+	list, err := mock.ListScenarios()
+	if err != nil {
+		http.Error(w, "Failed to fetch scenarios: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleGetMockScenario 获取单个场景的状态与数据
+func HandleGetMockScenario(w http.ResponseWriter, r *http.Request) {
+	writeMockScenario(w, r.PathValue("name"))
+}
+
+// HandleSetMockScenarioState 手动设置场景状态，Body: {"state": "..."}
+func HandleSetMockScenarioState(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	name := r.PathValue("name")
+	if err := mock.SetScenarioState(name, body.State); err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Scenario not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeMockScenario(w, name)
+}
+
+func writeMockScenario(w http.ResponseWriter, name string) {
+	sc, err := mock.GetScenario(name)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Scenario not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to fetch scenario: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sc)
+}
+
+// HandleResetMockScenario 重置单个场景 (回到初始状态并清空数据)
+func HandleResetMockScenario(w http.ResponseWriter, r *http.Request) {
+	mock.ResetScenario(r.PathValue("name"))
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Scenario reset"}`))
+}
+
+// HandleResetMockScenarios 重置所有场景
+func HandleResetMockScenarios(w http.ResponseWriter, r *http.Request) {
+	mock.ResetScenarios()
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "All scenarios reset"}`))
+}
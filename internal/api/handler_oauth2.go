@@ -0,0 +1,99 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/proxy"
+	"html"
+	"net/http"
+	"strconv"
+)
+
+// HandleListOAuth2Tokens 列出缓存的 OAuth 2.0 令牌及其状态 (不返回令牌本身)
+func HandleListOAuth2Tokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := database.GetAllOAuth2Tokens()
+	if err != nil {
+		http.Error(w, "Failed to fetch tokens: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	list := make([]proxy.OAuth2TokenStatus, 0, len(tokens))
+	for _, t := range tokens {
+		list = append(list, proxy.NewOAuth2TokenStatus(t))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// HandleFetchOAuth2Token 忽略缓存重新获取令牌 (client_credentials)
+// 请求体与发送请求的结构相同，使用其中的 auth.oauth2 配置与 environment_id
+func HandleFetchOAuth2Token(w http.ResponseWriter, r *http.Request) {
+	var req proxy.ProxyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	token, err := proxy.FetchOAuth2Token(req)
+	if err != nil {
+		http.Error(w, "Fetch token failed: "+err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(proxy.NewOAuth2TokenStatus(token))
+}
+
+// HandleDeleteOAuth2Token 删除缓存的令牌，下次发送时重新获取
+func HandleDeleteOAuth2Token(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := database.DeleteOAuth2Token(id); err != nil {
+		http.Error(w, "Failed to delete token: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Token deleted"}`))
+}
+
+// HandleAuthorizeOAuth2 开始授权码 + PKCE 流程，返回需要在浏览器中打开的授权地址
+// 未配置 redirect_uri 时使用本服务的 /api/oauth2/callback
+func HandleAuthorizeOAuth2(w http.ResponseWriter, r *http.Request) {
+	var req proxy.ProxyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+
+	redirect := fmt.Sprintf("http://%s/api/oauth2/callback", r.Host)
+	authURL, err := proxy.StartOAuth2Authorization(req, redirect)
+	if err != nil {
+		http.Error(w, "Authorize failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"authorization_url": authURL})
+}
+
+// HandleOAuth2Callback 授权服务器回调: 用授权码换取令牌，结果以页面形式展示给浏览器
+func HandleOAuth2Callback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+
+	if e := q.Get("error"); e != "" {
+		w.WriteHeader(http.StatusBadRequest)
+		fmt.Fprintf(w, "<h3>授权失败</h3><p>%s %s</p>", html.EscapeString(e), html.EscapeString(q.Get("error_description")))
+		return
+	}
+
+	if _, err := proxy.CompleteOAuth2Authorization(q.Get("state"), q.Get("code")); err != nil {
+		w.WriteHeader(http.StatusBadGateway)
+		fmt.Fprintf(w, "<h3>获取令牌失败</h3><p>%s</p>", html.EscapeString(err.Error()))
+		return
+	}
+	w.Write([]byte("<h3>授权成功</h3><p>令牌已保存，可以关闭此页面并重新发送请求。</p>"))
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"go-api-tester/internal/runner"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// HandleRunCollection 顺序运行分组 (含子分组) 中的所有请求并返回汇总报告
+// Body 可选，格式见 runner.Options
+func HandleRunCollection(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	opts, ok := decodeRunOptions(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := runner.RunCollection(id, opts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Collection not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to run collection: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// HandleRunRequest 运行单个已保存的请求 (包含断言评估)
+func HandleRunRequest(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+	opts, ok := decodeRunOptions(w, r)
+	if !ok {
+		return
+	}
+
+	report, err := runner.RunRequest(id, opts)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			http.Error(w, "Request not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "Failed to run request: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// decodeRunOptions 解析运行选项，允许空 Body
+func decodeRunOptions(w http.ResponseWriter, r *http.Request) (runner.Options, bool) {
+	var opts runner.Options
+	if err := json.NewDecoder(r.Body).Decode(&opts); err != nil && err != io.EOF {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return opts, false
+	}
+	return opts, true
+}
@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/proxy"
+	"net/http"
+)
+
+// HandleGetClientSettings 获取全局客户端设置
+func HandleGetClientSettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := database.GetClientSettings()
+	if err != nil {
+		http.Error(w, "Failed to fetch settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// HandleSaveClientSettings 保存全局客户端设置 (请求上的设置优先)
+func HandleSaveClientSettings(w http.ResponseWriter, r *http.Request) {
+	var settings database.ClientSettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if v := settings.HTTPVersion; v != "" && v != "1.1" && v != "2" {
+		http.Error(w, "http_version must be empty, 1.1 or 2", http.StatusBadRequest)
+		return
+	}
+
+	if err := database.SaveClientSettings(settings); err != nil {
+		http.Error(w, "Failed to save settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Settings saved"}`))
+}
+
+// HandleGetProxySettings 获取全局上游代理配置
+func HandleGetProxySettings(w http.ResponseWriter, r *http.Request) {
+	settings, err := database.GetProxySettings()
+	if err != nil {
+		http.Error(w, "Failed to fetch settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(settings)
+}
+
+// HandleSaveProxySettings 保存全局上游代理配置 (环境上的代理配置优先)
+func HandleSaveProxySettings(w http.ResponseWriter, r *http.Request) {
+	var settings database.ProxySettings
+	if err := json.NewDecoder(r.Body).Decode(&settings); err != nil {
+		http.Error(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := proxy.ValidateProxySettings(settings); err != nil {
+		http.Error(w, "Invalid proxy: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := database.SaveProxySettings(settings); err != nil {
+		http.Error(w, "Failed to save settings: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Settings saved"}`))
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// AttachmentDir 附件文件的存放目录，由 Open 根据数据库路径设置
+var AttachmentDir string
+
+// Attachment 对应数据库 attachments 表 (只保存元数据)
+type Attachment struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	ContentType string    `json:"content_type"`
+	Size        int64     `json:"size"`
+	SHA256      string    `json:"sha256"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// AttachmentPath 返回附件文件在磁盘上的路径
+func AttachmentPath(id int64) string {
+	return filepath.Join(AttachmentDir, strconv.FormatInt(id, 10))
+}
+
+// CreateAttachment 保存附件: 先写入元数据获取 ID，再将内容写入以 ID 命名的文件
+func CreateAttachment(name, contentType string, r io.Reader) (*Attachment, error) {
+	if err := os.MkdirAll(AttachmentDir, 0o755); err != nil {
+		return nil, fmt.Errorf("create attachment dir failed: %v", err)
+	}
+
+	result, err := DB.Exec(`INSERT INTO attachments (name, content_type) VALUES (?, ?)`, name, contentType)
+	if err != nil {
+		return nil, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+
+	size, sum, err := writeAttachmentFile(AttachmentPath(id), r)
+	if err != nil {
+		DB.Exec("DELETE FROM attachments WHERE id = ?", id)
+		return nil, err
+	}
+	if _, err := DB.Exec(`UPDATE attachments SET size=?, sha256=? WHERE id=?`, size, sum, id); err != nil {
+		return nil, err
+	}
+	return GetAttachment(id)
+}
+
+func writeAttachmentFile(path string, r io.Reader) (int64, string, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return 0, "", fmt.Errorf("create attachment file failed: %v", err)
+	}
+	h := sha256.New()
+	size, err := io.Copy(io.MultiWriter(f, h), r)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, "", fmt.Errorf("write attachment failed: %v", err)
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// GetAttachment 获取附件元数据
+func GetAttachment(id int64) (*Attachment, error) {
+	var a Attachment
+	err := DB.QueryRow(`SELECT id, name, COALESCE(content_type, ''), size, COALESCE(sha256, ''), created_at FROM attachments WHERE id = ?`, id).
+		Scan(&a.ID, &a.Name, &a.ContentType, &a.Size, &a.SHA256, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetAllAttachments 获取所有附件元数据 (按时间倒序)
+func GetAllAttachments() ([]*Attachment, error) {
+	rows, err := DB.Query(`SELECT id, name, COALESCE(content_type, ''), size, COALESCE(sha256, ''), created_at FROM attachments ORDER BY id DESC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Attachment
+	for rows.Next() {
+		var a Attachment
+		if err := rows.Scan(&a.ID, &a.Name, &a.ContentType, &a.Size, &a.SHA256, &a.CreatedAt); err != nil {
+			return nil, err
+		}
+		list = append(list, &a)
+	}
+	return list, rows.Err()
+}
+
+// OpenAttachment 打开附件文件，调用方负责关闭
+func OpenAttachment(id int64) (*os.File, *Attachment, error) {
+	a, err := GetAttachment(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	f, err := os.Open(AttachmentPath(id))
+	if err != nil {
+		return nil, nil, fmt.Errorf("open attachment file failed: %v", err)
+	}
+	return f, a, nil
+}
+
+// DeleteAttachment 删除附件及其文件
+func DeleteAttachment(id int64) error {
+	if _, err := DB.Exec("DELETE FROM attachments WHERE id = ?", id); err != nil {
+		return err
+	}
+	if err := os.Remove(AttachmentPath(id)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Certificate 对应数据库 certificates 表
+// 客户端证书与 CA 证书均可单独配置: 只填 CA 用于信任自签名服务端，只填证书+私钥用于 mTLS
+type Certificate struct {
+	ID          int64     `json:"id"`
+	Name        string    `json:"name"`
+	HostPattern string    `json:"host_pattern"` // 主机模式，* 匹配任意子域名，可带端口
+	ClientCert  string    `json:"client_cert"`  // PEM 格式客户端证书 (可含证书链)
+	ClientKey   string    `json:"client_key"`   // PEM 格式私钥
+	Passphrase  string    `json:"passphrase"`   // 私钥口令 (私钥未加密时留空)
+	CACert      string    `json:"ca_cert"`      // PEM 格式 CA 证书，可包含多个
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateCertificate 新增证书
+func CreateCertificate(c *Certificate) (int64, error) {
+	query := `
+		INSERT INTO certificates (name, host_pattern, client_cert, client_key, passphrase, ca_cert, enabled)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	result, err := DB.Exec(query, c.Name, normalizeHostPattern(c.HostPattern), c.ClientCert, c.ClientKey, c.Passphrase, c.CACert, c.Enabled)
+	if err != nil {
+		return 0, err
+	}
+	return result.LastInsertId()
+}
+
+// UpdateCertificate 更新证书
+func UpdateCertificate(c *Certificate) error {
+	query := `
+		UPDATE certificates SET name=?, host_pattern=?, client_cert=?, client_key=?, passphrase=?, ca_cert=?, enabled=?
+		WHERE id=?
+	`
+	_, err := DB.Exec(query, c.Name, normalizeHostPattern(c.HostPattern), c.ClientCert, c.ClientKey, c.Passphrase, c.CACert, c.Enabled, c.ID)
+	return err
+}
+
+// DeleteCertificate 删除证书
+func DeleteCertificate(id int64) error {
+	_, err := DB.Exec("DELETE FROM certificates WHERE id = ?", id)
+	return err
+}
+
+// GetCertificate 获取单个证书
+func GetCertificate(id int64) (*Certificate, error) {
+	row := DB.QueryRow(`
+		SELECT id, name, host_pattern, client_cert, client_key, passphrase, ca_cert, enabled, created_at
+		FROM certificates WHERE id = ?`, id)
+	return scanCertificate(row)
+}
+
+// GetAllCertificates 获取所有证书
+func GetAllCertificates() ([]*Certificate, error) {
+	rows, err := DB.Query(`
+		SELECT id, name, host_pattern, client_cert, client_key, passphrase, ca_cert, enabled, created_at
+		FROM certificates ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Certificate
+	for rows.Next() {
+		c, err := scanCertificate(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, c)
+	}
+	return list, rows.Err()
+}
+
+func scanCertificate(row rowScanner) (*Certificate, error) {
+	var c Certificate
+	var cert, key, pass, ca sql.NullString
+	if err := row.Scan(&c.ID, &c.Name, &c.HostPattern, &cert, &key, &pass, &ca, &c.Enabled, &c.CreatedAt); err != nil {
+		return nil, err
+	}
+	c.ClientCert, c.ClientKey, c.Passphrase, c.CACert = cert.String, key.String, pass.String, ca.String
+	return &c, nil
+}
+
+func normalizeHostPattern(p string) string {
+	return strings.ToLower(strings.TrimSpace(p))
+}
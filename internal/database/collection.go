@@ -1,6 +1,9 @@
 package database
 
 import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
 	"time"
 )
 
@@ -8,7 +11,10 @@ import (
 type Collection struct {
 	ID        int64         `json:"id"`
 	Name      string        `json:"name"`
-	ParentID  int64         `json:"parent_id"` // 0 表示根节点
+	ParentID  int64         `json:"parent_id"`         // 0 表示根节点
+	Variables []KeyValue    `json:"variables"`         // 分组变量，子分组与其中的请求均可继承
+	Auth      *AuthConfig   `json:"auth,omitempty"`    // 分组认证，认证类型为 inherit 的请求与子分组沿用
+	Scripts   *Scripts      `json:"scripts,omitempty"` // 分组脚本，在其中所有请求的脚本之前执行
 	CreatedAt time.Time     `json:"created_at"`
 	Children  []*Collection `json:"children,omitempty"` // 用于构建树状结构
 }
@@ -23,6 +29,96 @@ func CreateCollection(name string, parentID int64) (int64, error) {
 	return result.LastInsertId()
 }
 
+// ValidateCollectionParent 检查分组 id 能否放到 parentID 下 (新建分组时 id 为 0)
+// 父分组必须存在，且不能是该分组自身或其子孙分组，否则会形成环，整个环在分组树中都将不可见
+func ValidateCollectionParent(id, parentID int64) error {
+	seen := make(map[int64]bool)
+	for cur := parentID; cur != 0 && !seen[cur]; {
+		if cur == id {
+			return fmt.Errorf("collection cannot be moved under itself or its descendants")
+		}
+		seen[cur] = true
+		var next int64
+		err := DB.QueryRow("SELECT parent_id FROM collections WHERE id = ?", cur).Scan(&next)
+		if err == sql.ErrNoRows {
+			if cur == parentID {
+				return fmt.Errorf("parent collection %d not found", parentID)
+			}
+			break // 祖先链中的孤儿节点按根节点处理
+		}
+		if err != nil {
+			return err
+		}
+		cur = next
+	}
+	return nil
+}
+
+// UpdateCollection 更新分组名称、父节点、变量、认证与脚本
+func UpdateCollection(c *Collection) error {
+	if c.ParentID == c.ID {
+		return fmt.Errorf("collection cannot be its own parent")
+	}
+	varsJSON, err := json.Marshal(c.Variables)
+	if err != nil {
+		return fmt.Errorf("marshal variables failed: %v", err)
+	}
+	authJSON, err := marshalNullable(c.Auth)
+	if err != nil {
+		return err
+	}
+	scriptsJSON, err := marshalNullable(c.Scripts)
+	if err != nil {
+		return err
+	}
+
+	query := "UPDATE collections SET name=?, parent_id=?, variables=?, auth=?, scripts=? WHERE id=?"
+	_, err = DB.Exec(query, c.Name, c.ParentID, string(varsJSON), authJSON, scriptsJSON, c.ID)
+	return err
+}
+
+// SetCollectionVariables 仅更新分组变量
+func SetCollectionVariables(id int64, vars []KeyValue) error {
+	varsJSON, err := json.Marshal(vars)
+	if err != nil {
+		return fmt.Errorf("marshal variables failed: %v", err)
+	}
+	_, err = DB.Exec("UPDATE collections SET variables=? WHERE id=?", string(varsJSON), id)
+	return err
+}
+
+// SetCollectionAuth 仅更新分组认证，auth 为 nil 表示清除
+func SetCollectionAuth(id int64, auth *AuthConfig) error {
+	authJSON, err := marshalNullable(auth)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec("UPDATE collections SET auth=? WHERE id=?", authJSON, id)
+	return err
+}
+
+// SetCollectionScripts 仅更新分组脚本，scripts 为 nil 表示清除
+func SetCollectionScripts(id int64, scripts *Scripts) error {
+	scriptsJSON, err := marshalNullable(scripts)
+	if err != nil {
+		return err
+	}
+	_, err = DB.Exec("UPDATE collections SET scripts=? WHERE id=?", scriptsJSON, id)
+	return err
+}
+
+// marshalNullable 序列化为 JSON 字符串，nil 指针存为 NULL
+func marshalNullable[T any](v *T) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("marshal %T failed: %v", v, err)
+	}
+	return string(b), nil
+}
+
 // DeleteCollection 删除分组
 func DeleteCollection(id int64) error {
 	query := "DELETE FROM collections WHERE id = ?"
@@ -62,7 +158,7 @@ func GetAllCollections() ([]*Collection, error) {
 
 // GetAllCollectionsFlat 获取所有分组（扁平结构，用于导出）
 func GetAllCollectionsFlat() ([]*Collection, error) {
-	rows, err := DB.Query("SELECT id, name, parent_id, created_at FROM collections ORDER BY id ASC")
+	rows, err := DB.Query("SELECT id, name, parent_id, variables, auth, scripts, created_at FROM collections ORDER BY id ASC")
 	if err != nil {
 		return nil, err
 	}
@@ -71,11 +167,52 @@ func GetAllCollectionsFlat() ([]*Collection, error) {
 	var list []*Collection
 	for rows.Next() {
 		c := &Collection{}
-		if err := rows.Scan(&c.ID, &c.Name, &c.ParentID, &c.CreatedAt); err != nil {
+		var varsStr, authStr, scriptsStr sql.NullString
+		if err := rows.Scan(&c.ID, &c.Name, &c.ParentID, &varsStr, &authStr, &scriptsStr, &c.CreatedAt); err != nil {
 			return nil, err
 		}
+		if varsStr.Valid && varsStr.String != "" {
+			_ = json.Unmarshal([]byte(varsStr.String), &c.Variables)
+		}
+		if authStr.Valid && authStr.String != "" {
+			var auth AuthConfig
+			if json.Unmarshal([]byte(authStr.String), &auth) == nil {
+				c.Auth = &auth
+			}
+		}
+		if scriptsStr.Valid && scriptsStr.String != "" {
+			var scripts Scripts
+			if json.Unmarshal([]byte(scriptsStr.String), &scripts) == nil {
+				c.Scripts = &scripts
+			}
+		}
+		if c.Variables == nil {
+			c.Variables = []KeyValue{}
+		}
 		c.Children = []*Collection{} // 初始化为空切片
 		list = append(list, c)
 	}
 	return list, nil
-}
\ No newline at end of file
+}
+
+// GetCollectionPath 在 GetAllCollections 构建的树中查找分组，返回从根节点到该分组的路径
+// 分组不存在时返回空切片
+func GetCollectionPath(id int64) ([]*Collection, error) {
+	roots, err := GetAllCollections()
+	if err != nil {
+		return nil, err
+	}
+	return findCollectionPath(roots, id), nil
+}
+
+func findCollectionPath(nodes []*Collection, id int64) []*Collection {
+	for _, c := range nodes {
+		if c.ID == id {
+			return []*Collection{c}
+		}
+		if sub := findCollectionPath(c.Children, id); sub != nil {
+			return append([]*Collection{c}, sub...)
+		}
+	}
+	return nil
+}
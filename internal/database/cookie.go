@@ -0,0 +1,140 @@
+package database
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+)
+
+// Cookie 对应数据库 cookies 表
+type Cookie struct {
+	ID            int64      `json:"id"`
+	EnvironmentID int64      `json:"environment_id"`
+	Domain        string     `json:"domain"`
+	Path          string     `json:"path"`
+	Name          string     `json:"name"`
+	Value         string     `json:"value"`
+	Expires       *time.Time `json:"expires,omitempty"` // nil 表示会话 Cookie，程序重新启动时清除
+	Secure        bool       `json:"secure"`
+	HttpOnly      bool       `json:"http_only"`
+	HostOnly      bool       `json:"host_only"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Expired 判断 Cookie 是否已过期
+func (c *Cookie) Expired(now time.Time) bool {
+	return c.Expires != nil && !c.Expires.After(now)
+}
+
+// SaveCookie 新增或覆盖 Cookie (以 环境 + 域名 + 路径 + 名称 唯一确定)
+func SaveCookie(c *Cookie) (int64, error) {
+	normalizeCookie(c)
+	query := `
+		INSERT INTO cookies (environment_id, domain, path, name, value, expires, secure, http_only, host_only)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(environment_id, domain, path, name) DO UPDATE SET
+			value=excluded.value, expires=excluded.expires, secure=excluded.secure,
+			http_only=excluded.http_only, host_only=excluded.host_only
+	`
+	_, err := DB.Exec(query, c.EnvironmentID, c.Domain, c.Path, c.Name, c.Value, nullTime(c.Expires), c.Secure, c.HttpOnly, c.HostOnly)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = DB.QueryRow(`SELECT id FROM cookies WHERE environment_id=? AND domain=? AND path=? AND name=?`,
+		c.EnvironmentID, c.Domain, c.Path, c.Name).Scan(&id)
+	return id, err
+}
+
+// UpdateCookie 按 ID 更新 Cookie
+func UpdateCookie(c *Cookie) error {
+	normalizeCookie(c)
+	query := `
+		UPDATE cookies SET environment_id=?, domain=?, path=?, name=?, value=?, expires=?, secure=?, http_only=?, host_only=?
+		WHERE id=?
+	`
+	_, err := DB.Exec(query, c.EnvironmentID, c.Domain, c.Path, c.Name, c.Value, nullTime(c.Expires), c.Secure, c.HttpOnly, c.HostOnly, c.ID)
+	return err
+}
+
+// DeleteCookie 删除单个 Cookie
+func DeleteCookie(id int64) error {
+	_, err := DB.Exec("DELETE FROM cookies WHERE id = ?", id)
+	return err
+}
+
+// DeleteCookieByKey 按 环境 + 域名 + 路径 + 名称 删除 Cookie (服务端要求删除时使用)
+func DeleteCookieByKey(envID int64, domain, path, name string) error {
+	_, err := DB.Exec("DELETE FROM cookies WHERE environment_id=? AND domain=? AND path=? AND name=?",
+		envID, strings.ToLower(domain), path, name)
+	return err
+}
+
+// ClearCookies 清空 Cookie，domain 为空时清空整个环境
+func ClearCookies(envID int64, domain string) error {
+	if domain == "" {
+		_, err := DB.Exec("DELETE FROM cookies WHERE environment_id = ?", envID)
+		return err
+	}
+	_, err := DB.Exec("DELETE FROM cookies WHERE environment_id = ? AND domain = ?", envID, strings.ToLower(domain))
+	return err
+}
+
+// clearSessionCookies 清除上次运行遗留的会话 Cookie (未设置 Expires / Max-Age)
+func clearSessionCookies() error {
+	_, err := DB.Exec("DELETE FROM cookies WHERE expires IS NULL")
+	return err
+}
+
+// GetCookies 查询 Cookie，envID 为 nil 时返回所有环境，domain 为空时不过滤域名
+func GetCookies(envID *int64, domain string) ([]*Cookie, error) {
+	query := `SELECT id, environment_id, domain, path, name, value, expires, secure, http_only, host_only, created_at FROM cookies WHERE 1=1`
+	var args []interface{}
+	if envID != nil {
+		query += " AND environment_id = ?"
+		args = append(args, *envID)
+	}
+	if domain != "" {
+		query += " AND domain = ?"
+		args = append(args, strings.ToLower(domain))
+	}
+	query += " ORDER BY domain ASC, path ASC, name ASC"
+
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Cookie
+	for rows.Next() {
+		var c Cookie
+		var value sql.NullString
+		var expires sql.NullTime
+		if err := rows.Scan(&c.ID, &c.EnvironmentID, &c.Domain, &c.Path, &c.Name, &value, &expires, &c.Secure, &c.HttpOnly, &c.HostOnly, &c.CreatedAt); err != nil {
+			return nil, err
+		}
+		c.Value = value.String
+		if expires.Valid {
+			t := expires.Time
+			c.Expires = &t
+		}
+		list = append(list, &c)
+	}
+	return list, nil
+}
+
+func normalizeCookie(c *Cookie) {
+	c.Domain = strings.ToLower(strings.TrimPrefix(c.Domain, "."))
+	if c.Path == "" {
+		c.Path = "/"
+	}
+}
+
+func nullTime(t *time.Time) interface{} {
+	if t == nil {
+		return nil
+	}
+	return t.UTC()
+}
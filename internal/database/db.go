@@ -13,24 +13,48 @@ var DB *sql.DB
 
 // InitDB 初始化数据库连接并创建表结构
 func InitDB() error {
-	ex, err := os.Executable()
+	dbPath, err := DefaultPath()
 	if err != nil {
 		return err
 	}
+
+	fmt.Printf("正在初始化数据库: %s\n", dbPath)
+
+	return Open(dbPath)
+}
+
+// DefaultPath 返回默认数据库路径: 可执行文件同目录下的 api_tester.db
+// 在源码目录 (存在 go.mod) 下运行时使用当前目录
+func DefaultPath() (string, error) {
+	ex, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
 	dbPath := filepath.Join(filepath.Dir(ex), "api_tester.db")
 
 	if _, err := os.Stat("go.mod"); err == nil {
 		dbPath = "api_tester.db"
 	}
+	return dbPath, nil
+}
 
-	fmt.Printf("正在初始化数据库: %s\n", dbPath)
-
+// Open 打开指定路径的数据库并创建表结构
+// path 为 ":memory:" 时使用内存数据库 (命令行运行导出文件时使用)
+func Open(path string) error {
 	var dbErr error
-	DB, dbErr = sql.Open("sqlite", dbPath)
+	DB, dbErr = sql.Open("sqlite", path)
 	if dbErr != nil {
 		return fmt.Errorf("无法打开数据库: %v", dbErr)
 	}
 
+	// 每个内存数据库连接都是独立的库，必须限制为单连接
+	if path == ":memory:" {
+		DB.SetMaxOpenConns(1)
+		AttachmentDir = filepath.Join(os.TempDir(), "go-api-tester-attachments")
+	} else {
+		AttachmentDir = filepath.Join(filepath.Dir(path), "attachments")
+	}
+
 	if err := DB.Ping(); err != nil {
 		return fmt.Errorf("无法连接数据库: %v", err)
 	}
@@ -39,7 +63,10 @@ func InitDB() error {
 		return err
 	}
 
-	return createTables()
+	if err := createTables(); err != nil {
+		return err
+	}
+	return clearSessionCookies()
 }
 
 func createTables() error {
@@ -81,6 +108,83 @@ func createTables() error {
 		config TEXT, -- 存储完整请求配置
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
 	);
+
+	-- 环境变量表
+	CREATE TABLE IF NOT EXISTS environments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		variables TEXT, -- JSON 数组: [{key, value, enabled}]
+		is_active BOOLEAN DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- Cookie 存储 (按环境隔离，environment_id 为 0 表示未使用环境)
+	CREATE TABLE IF NOT EXISTS cookies (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		environment_id INTEGER DEFAULT 0,
+		domain TEXT NOT NULL,
+		path TEXT NOT NULL DEFAULT '/',
+		name TEXT NOT NULL,
+		value TEXT,
+		expires DATETIME, -- NULL 表示会话 Cookie
+		secure BOOLEAN DEFAULT 0,
+		http_only BOOLEAN DEFAULT 0,
+		host_only BOOLEAN DEFAULT 1, -- 未设置 Domain 属性时只发送给完全相同的主机
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE(environment_id, domain, path, name)
+	);
+
+	-- 客户端证书 (mTLS) 与自定义 CA，按主机模式匹配
+	CREATE TABLE IF NOT EXISTS certificates (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		host_pattern TEXT NOT NULL, -- 例如 api.internal、*.corp.local、api.internal:8443
+		client_cert TEXT, -- PEM
+		client_key TEXT, -- PEM，可加密
+		passphrase TEXT,
+		ca_cert TEXT, -- PEM，可包含多个证书
+		enabled BOOLEAN DEFAULT 1,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- 上传的附件 (文件内容保存在 AttachmentDir 目录，以 ID 命名)
+	CREATE TABLE IF NOT EXISTS attachments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL, -- 原始文件名，上传时作为 filename
+		content_type TEXT,
+		size INTEGER DEFAULT 0,
+		sha256 TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- GraphQL schema 缓存 (按请求地址)
+	CREATE TABLE IF NOT EXISTS graphql_schemas (
+		url TEXT PRIMARY KEY,
+		introspection TEXT, -- __schema 的 JSON
+		sdl TEXT,
+		fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- OAuth 2.0 令牌缓存 (cache_key 由授权方式、令牌地址、client_id、scope 计算)
+	CREATE TABLE IF NOT EXISTS oauth2_tokens (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		cache_key TEXT NOT NULL UNIQUE,
+		grant_type TEXT,
+		token_url TEXT,
+		client_id TEXT,
+		scope TEXT,
+		access_token TEXT,
+		refresh_token TEXT,
+		token_type TEXT,
+		expires_at DATETIME, -- NULL 表示未声明过期时间
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	);
+
+	-- 全局设置 (键值对，值为 JSON)
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT
+	);
 	`
 
 	_, err := DB.Exec(schema)
@@ -88,9 +192,107 @@ func createTables() error {
 		return fmt.Errorf("创建表结构失败: %v", err)
 	}
 
+	if err := migrateColumns(); err != nil {
+		return err
+	}
+	return createHistorySearch()
+}
+
+// createHistorySearch 创建历史记录的全文索引 (FTS5, trigram 分词支持任意子串与中文)
+// 索引内容在写入历史时由 CreateHistoryWithResponse 插入，删除历史时由触发器同步删除
+func createHistorySearch() error {
+	schema := `
+	CREATE VIRTUAL TABLE IF NOT EXISTS history_fts USING fts5(url, request_body, response_body, tokenize = 'trigram');
+
+	CREATE TRIGGER IF NOT EXISTS history_fts_delete AFTER DELETE ON history BEGIN
+		DELETE FROM history_fts WHERE rowid = old.id;
+	END;
+
+	-- 为升级前的历史记录补充索引 (只包含 URL，规则同 indexURL)
+	INSERT INTO history_fts (rowid, url, request_body, response_body)
+		SELECT id,
+			CASE WHEN coalesce(resolved_url, '') IN ('', url) THEN url ELSE resolved_url || char(10) || url END,
+			'', ''
+		FROM history WHERE id NOT IN (SELECT rowid FROM history_fts);
+	`
+	if _, err := DB.Exec(schema); err != nil {
+		return fmt.Errorf("创建历史索引失败: %v", err)
+	}
 	return nil
 }
 
+// columnMigrations 为已存在的旧表补充新增列
+// CREATE TABLE IF NOT EXISTS 不会修改已有表，因此新增列统一在这里声明
+var columnMigrations = []struct {
+	Table      string
+	Column     string
+	Definition string
+}{
+	{"collections", "variables", "TEXT"},
+	{"environments", "proxy", "TEXT"},
+	{"collections", "auth", "TEXT"},
+	{"collections", "scripts", "TEXT"},
+	{"history", "status_code", "INTEGER DEFAULT 0"},
+	{"history", "time_ms", "INTEGER DEFAULT 0"},
+	{"history", "error", "TEXT"},
+	{"history", "response_size", "INTEGER DEFAULT 0"},
+	{"history", "response", "BLOB"}, // gzip 压缩的响应 JSON
+	{"history", "environment_id", "INTEGER DEFAULT 0"},
+	{"history", "resolved_url", "TEXT"}, // 变量替换后实际发送的 URL
+	{"history", "sent_config", "TEXT"},  // 变量替换后实际发送的请求配置 (JSON)
+	{"mock_rules", "responses", "TEXT"}, // 条件响应 (JSON 数组)
+	{"mock_rules", "delay_ms", "INTEGER DEFAULT 0"},
+	{"mock_rules", "jitter_ms", "INTEGER DEFAULT 0"},
+	{"mock_rules", "fault", "TEXT"},
+	{"mock_rules", "scenario", "TEXT"},
+	{"mock_rules", "required_state", "TEXT"},
+	{"mock_rules", "new_state", "TEXT"},
+	{"mock_rules", "template", "INTEGER DEFAULT 0"}, // 旧规则默认不按模板渲染
+}
+
+func migrateColumns() error {
+	for _, m := range columnMigrations {
+		exists, err := columnExists(m.Table, m.Column)
+		if err != nil {
+			return fmt.Errorf("检查表结构失败: %v", err)
+		}
+		if exists {
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", m.Table, m.Column, m.Definition)
+		if _, err := DB.Exec(stmt); err != nil {
+			return fmt.Errorf("升级表结构失败 (%s.%s): %v", m.Table, m.Column, err)
+		}
+	}
+	return nil
+}
+
+func columnExists(table, column string) (bool, error) {
+	rows, err := DB.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return false, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return false, err
+		}
+		if name == column {
+			return true, nil
+		}
+	}
+	return false, rows.Err()
+}
+
 func Close() {
 	if DB != nil {
 		DB.Close()
@@ -0,0 +1,224 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Environment 对应数据库 environments 表
+type Environment struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Variables []KeyValue `json:"variables"` // 变量列表，仅 Enabled 的条目参与替换
+	IsActive  bool       `json:"is_active"` // 当前激活的环境 (全局唯一)
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Proxy 环境专用的上游代理，为空或 Mode 为空时沿用全局代理配置
+	Proxy *ProxySettings `json:"proxy,omitempty"`
+}
+
+// VariableMap 返回环境中启用的变量
+func (e *Environment) VariableMap() map[string]string {
+	return VariableMap(e.Variables)
+}
+
+// CreateEnvironment 创建环境
+func CreateEnvironment(env *Environment) (int64, error) {
+	varsJSON, err := json.Marshal(env.Variables)
+	if err != nil {
+		return 0, fmt.Errorf("marshal variables failed: %v", err)
+	}
+
+	proxyJSON, err := marshalProxy(env.Proxy)
+	if err != nil {
+		return 0, err
+	}
+
+	query := `INSERT INTO environments (name, variables, is_active, proxy) VALUES (?, ?, ?, ?)`
+	result, err := DB.Exec(query, env.Name, string(varsJSON), env.IsActive, proxyJSON)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+
+	// 新环境被标记为激活时，保证其他环境取消激活
+	if env.IsActive {
+		if err := SetActiveEnvironment(id); err != nil {
+			return 0, err
+		}
+	}
+	return id, nil
+}
+
+// UpdateEnvironment 更新环境
+func UpdateEnvironment(env *Environment) error {
+	varsJSON, err := json.Marshal(env.Variables)
+	if err != nil {
+		return fmt.Errorf("marshal variables failed: %v", err)
+	}
+
+	proxyJSON, err := marshalProxy(env.Proxy)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE environments SET name=?, variables=?, is_active=?, proxy=? WHERE id=?`
+	if _, err := DB.Exec(query, env.Name, string(varsJSON), env.IsActive, proxyJSON, env.ID); err != nil {
+		return err
+	}
+
+	if env.IsActive {
+		return SetActiveEnvironment(env.ID)
+	}
+	return nil
+}
+
+// envVarMu 串行化 SetEnvironmentVariable，并行发送的请求同时写入变量时不会互相覆盖
+var envVarMu sync.Mutex
+
+// SetEnvironmentVariable 设置环境中的单个变量，已存在则覆盖值，否则追加为启用状态
+// 读取与写回在同一事务中完成
+func SetEnvironmentVariable(id int64, key, value string) error {
+	envVarMu.Lock()
+	defer envVarMu.Unlock()
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var varsStr string
+	if err := tx.QueryRow("SELECT variables FROM environments WHERE id = ?", id).Scan(&varsStr); err != nil {
+		return err
+	}
+	var vars []KeyValue
+	if varsStr != "" {
+		_ = json.Unmarshal([]byte(varsStr), &vars)
+	}
+
+	found := false
+	for i := range vars {
+		if vars[i].Key == key {
+			vars[i].Value = value
+			vars[i].Enabled = true
+			found = true
+		}
+	}
+	if !found {
+		vars = append(vars, KeyValue{Key: key, Value: value, Enabled: true})
+	}
+
+	varsJSON, err := json.Marshal(vars)
+	if err != nil {
+		return fmt.Errorf("marshal variables failed: %v", err)
+	}
+	if _, err := tx.Exec("UPDATE environments SET variables=? WHERE id=?", string(varsJSON), id); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// SetActiveEnvironment 激活指定环境，id 为 0 表示不使用任何环境
+func SetActiveEnvironment(id int64) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("UPDATE environments SET is_active = 0"); err != nil {
+		return err
+	}
+	if id != 0 {
+		if _, err := tx.Exec("UPDATE environments SET is_active = 1 WHERE id = ?", id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetEnvironment 获取单个环境
+func GetEnvironment(id int64) (*Environment, error) {
+	query := `SELECT id, name, variables, is_active, created_at, proxy FROM environments WHERE id = ?`
+	return scanEnvironment(DB.QueryRow(query, id))
+}
+
+// GetActiveEnvironment 获取当前激活的环境，没有激活环境时返回 sql.ErrNoRows
+func GetActiveEnvironment() (*Environment, error) {
+	query := `SELECT id, name, variables, is_active, created_at, proxy FROM environments WHERE is_active = 1 LIMIT 1`
+	return scanEnvironment(DB.QueryRow(query))
+}
+
+// GetAllEnvironments 获取所有环境
+func GetAllEnvironments() ([]*Environment, error) {
+	rows, err := DB.Query(`SELECT id, name, variables, is_active, created_at, proxy FROM environments ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*Environment
+	for rows.Next() {
+		env, err := scanEnvironment(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, env)
+	}
+	return list, nil
+}
+
+// DeleteEnvironment 删除环境
+func DeleteEnvironment(id int64) error {
+	if _, err := DB.Exec("DELETE FROM environments WHERE id = ?", id); err != nil {
+		return err
+	}
+	// 同时清理该环境下的 Cookie
+	return ClearCookies(id, "")
+}
+
+// rowScanner 兼容 *sql.Row 与 *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanEnvironment(row rowScanner) (*Environment, error) {
+	var env Environment
+	var varsStr string
+	var proxyStr sql.NullString
+	if err := row.Scan(&env.ID, &env.Name, &varsStr, &env.IsActive, &env.CreatedAt, &proxyStr); err != nil {
+		return nil, err
+	}
+	if proxyStr.String != "" {
+		var p ProxySettings
+		if json.Unmarshal([]byte(proxyStr.String), &p) == nil {
+			env.Proxy = &p
+		}
+	}
+	if varsStr != "" {
+		_ = json.Unmarshal([]byte(varsStr), &env.Variables)
+	}
+	if env.Variables == nil {
+		env.Variables = []KeyValue{}
+	}
+	return &env, nil
+}
+
+// marshalProxy 序列化环境代理配置，未配置时存储 NULL
+func marshalProxy(p *ProxySettings) (interface{}, error) {
+	if p == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, fmt.Errorf("marshal proxy failed: %v", err)
+	}
+	return string(data), nil
+}
@@ -0,0 +1,40 @@
+package database
+
+import (
+	"time"
+)
+
+// GraphQLSchema 对应数据库 graphql_schemas 表
+type GraphQLSchema struct {
+	URL           string    `json:"url"`
+	Introspection string    `json:"-"` // __schema 的 JSON
+	SDL           string    `json:"sdl"`
+	FetchedAt     time.Time `json:"fetched_at"`
+}
+
+// SaveGraphQLSchema 保存 (覆盖) 指定地址的 schema 缓存
+func SaveGraphQLSchema(s *GraphQLSchema) error {
+	query := `
+		INSERT INTO graphql_schemas (url, introspection, sdl, fetched_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(url) DO UPDATE SET introspection=excluded.introspection, sdl=excluded.sdl, fetched_at=excluded.fetched_at
+	`
+	_, err := DB.Exec(query, s.URL, s.Introspection, s.SDL, s.FetchedAt)
+	return err
+}
+
+// GetGraphQLSchema 获取指定地址的 schema 缓存，不存在时返回 sql.ErrNoRows
+func GetGraphQLSchema(url string) (*GraphQLSchema, error) {
+	var s GraphQLSchema
+	err := DB.QueryRow(`SELECT url, introspection, sdl, fetched_at FROM graphql_schemas WHERE url = ?`, url).
+		Scan(&s.URL, &s.Introspection, &s.SDL, &s.FetchedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// DeleteGraphQLSchema 删除指定地址的 schema 缓存
+func DeleteGraphQLSchema(url string) error {
+	_, err := DB.Exec("DELETE FROM graphql_schemas WHERE url = ?", url)
+	return err
+}
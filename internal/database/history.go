@@ -1,9 +1,27 @@
 package database
 
 import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
 	"time"
+	"unicode/utf8"
+)
+
+// MaxHistoryBodySize 历史记录中保存的响应体上限 (字节)，超出部分截断
+const MaxHistoryBodySize = 1 << 20
+
+// historyIndexBodySize 全文索引中每个请求体 / 响应体最多收录的字节数
+const historyIndexBodySize = 64 << 10
+
+// 历史列表每页条数
+const (
+	DefaultHistoryPageSize = 100
+	MaxHistoryPageSize     = 500
 )
 
 // HistoryItem 对应数据库 history 表
@@ -14,69 +32,353 @@ type HistoryItem struct {
 	Config    string    `json:"-"`       // 内部使用
 	Request   *Request  `json:"request"` // 解析后的完整请求数据
 	CreatedAt time.Time `json:"created_at"`
+
+	// 响应摘要，手动创建 (未经代理发送) 的记录均为零值
+	StatusCode   int    `json:"status"`
+	TimeMs       int64  `json:"time_ms"`
+	Error        string `json:"error,omitempty"`
+	ResponseSize int    `json:"response_size"` // 截断前的响应体长度
+
+	EnvironmentID int64 `json:"environment_id"` // 发送时使用的环境，0 表示未使用环境
+
+	// ResolvedURL 变量替换后实际发送的 URL (含查询参数)，URL 保留替换前的模板
+	// 请求未能发送 (例如变量缺失) 或手动创建的记录为空
+	ResolvedURL string `json:"resolved_url,omitempty"`
+
+	// Sent 变量替换后实际发送的请求 (URL 已合并查询参数)，仅在按 ID 获取时加载
+	Sent *Request `json:"sent,omitempty"`
+
+	// Response 完整响应，仅在按 ID 获取时加载
+	Response *HistoryResponse `json:"response,omitempty"`
+}
+
+// HistoryResponse 保存的响应，压缩后存入 response 列
+type HistoryResponse struct {
+	StatusCode int                 `json:"status"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body"`
+	IsBinary   bool                `json:"is_binary"`
+	Truncated  bool                `json:"truncated,omitempty"` // 响应体超过 MaxHistoryBodySize 被截断
+	TimeMs     int64               `json:"time_ms"`
+	Timing     json.RawMessage     `json:"timing,omitempty"`
+	Protocol   string              `json:"protocol,omitempty"`
+	Error      string              `json:"error,omitempty"`
 }
 
 // CreateHistory 添加历史记录
 func CreateHistory(req *Request) (int64, error) {
-	configData := map[string]interface{}{
-		"params":  req.Params,
-		"headers": req.Headers,
-		"auth":    req.Auth,
-		"body":    req.Body,
+	return CreateHistoryWithResponse(req, nil, 0, nil)
+}
+
+// CreateHistoryWithResponse 添加历史记录并保存响应
+// req 为替换前的请求模板，sent 为变量替换后实际发送的请求，sent 或 resp 为 nil 时不保存对应内容
+// 同时写入全文索引 (URL、文本请求体与文本响应体)
+func CreateHistoryWithResponse(req, sent *Request, envID int64, resp *HistoryResponse) (int64, error) {
+	configJSON, err := marshalRequestConfig(req)
+	if err != nil {
+		return 0, err
+	}
+	var resolvedURL, sentJSON sql.NullString
+	if sent != nil {
+		resolvedURL = sql.NullString{String: sent.URL, Valid: true}
+		if sentJSON.String, err = marshalSentRequest(sent); err != nil {
+			return 0, err
+		}
+		sentJSON.Valid = true
 	}
-	configJSON, err := json.Marshal(configData)
+
+	var (
+		status, size int
+		timeMs       int64
+		errMsg       string
+		blob         []byte
+	)
+	if resp != nil {
+		status, timeMs, errMsg, size = resp.StatusCode, resp.TimeMs, resp.Error, len(resp.Body)
+		if blob, err = compressHistoryResponse(*resp); err != nil {
+			return 0, err
+		}
+	}
+
+	tx, err := DB.Begin()
 	if err != nil {
-		return 0, fmt.Errorf("marshal config failed: %v", err)
+		return 0, err
 	}
+	defer tx.Rollback()
 
-	query := `INSERT INTO history (method, url, config, created_at) VALUES (?, ?, ?, CURRENT_TIMESTAMP)`
-	result, err := DB.Exec(query, req.Method, req.URL, string(configJSON))
+	query := `INSERT INTO history (method, url, config, status_code, time_ms, error, response_size, response, environment_id, resolved_url, sent_config, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
+	result, err := tx.Exec(query, req.Method, req.URL, configJSON, status, timeMs, errMsg, size, blob, envID, resolvedURL, sentJSON)
+	if err != nil {
+		return 0, err
+	}
+	id, err := result.LastInsertId()
 	if err != nil {
 		return 0, err
 	}
-	return result.LastInsertId()
+
+	requestBody := req.Body.RawContent
+	if req.Body.Type == "graphql" {
+		requestBody = req.Body.GraphQLQuery + "\n" + req.Body.GraphQLVars
+	}
+	responseBody := ""
+	if resp != nil && !resp.IsBinary {
+		responseBody = resp.Body
+	}
+	_, err = tx.Exec(`INSERT INTO history_fts (rowid, url, request_body, response_body) VALUES (?, ?, ?, ?)`,
+		id, indexURL(req.URL, resolvedURL.String), indexText(requestBody), indexText(responseBody))
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// marshalSentRequest 序列化实际发送的请求，请求体超过 MaxHistoryBodySize 时截断
+func marshalSentRequest(sent *Request) (string, error) {
+	copied := *sent
+	if len(copied.Body.RawContent) > MaxHistoryBodySize {
+		copied.Body.RawContent = strings.ToValidUTF8(copied.Body.RawContent[:MaxHistoryBodySize], "")
+	}
+	return marshalRequestConfig(&copied)
+}
+
+// indexURL 全文索引中的 URL: 实际发送的 URL 在前，与模板不同时同时收录模板
+// 与 createHistorySearch 中补充索引的规则保持一致
+func indexURL(template, resolved string) string {
+	if resolved == "" || resolved == template {
+		return template
+	}
+	return resolved + "\n" + template
+}
+
+// indexText 截取前 historyIndexBodySize 字节 (不截断 UTF-8 字符)
+func indexText(s string) string {
+	if len(s) <= historyIndexBodySize {
+		return s
+	}
+	return strings.ToValidUTF8(s[:historyIndexBodySize], "")
+}
+
+// compressHistoryResponse 截断过大的响应体后序列化并 gzip 压缩
+func compressHistoryResponse(resp HistoryResponse) ([]byte, error) {
+	if len(resp.Body) > MaxHistoryBodySize {
+		if resp.IsBinary {
+			resp.Body = resp.Body[:MaxHistoryBodySize-MaxHistoryBodySize%4] // Base64 按 4 字符对齐，截断后仍可解码
+		} else {
+			resp.Body = strings.ToValidUTF8(resp.Body[:MaxHistoryBodySize], "") // 不截断 UTF-8 字符
+		}
+		resp.Truncated = true
+	}
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		return nil, fmt.Errorf("marshal response failed: %v", err)
+	}
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decompressHistoryResponse(blob []byte) (*HistoryResponse, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(blob))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, err
+	}
+	resp := &HistoryResponse{}
+	if err := json.Unmarshal(data, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
 }
 
-// GetHistory 获取历史记录列表 (按时间倒序，限制最近 100 条)
-func GetHistory() ([]*HistoryItem, error) {
-	query := `SELECT id, method, url, config, created_at FROM history ORDER BY created_at DESC LIMIT 100`
-	rows, err := DB.Query(query)
+const historyColumns = `id, method, url, config, created_at, status_code, time_ms, error, response_size, environment_id, resolved_url`
+
+func scanHistoryItem(row rowScanner, extra ...interface{}) (*HistoryItem, error) {
+	item := &HistoryItem{}
+	var (
+		status, size sql.NullInt64
+		timeMs       sql.NullInt64
+		errMsg       sql.NullString
+		envID        sql.NullInt64
+		resolvedURL  sql.NullString
+	)
+	dest := append([]interface{}{&item.ID, &item.Method, &item.URL, &item.Config, &item.CreatedAt, &status, &timeMs, &errMsg, &size, &envID, &resolvedURL}, extra...)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	item.StatusCode = int(status.Int64)
+	item.TimeMs = timeMs.Int64
+	item.Error = errMsg.String
+	item.ResponseSize = int(size.Int64)
+	item.EnvironmentID = envID.Int64
+	item.ResolvedURL = resolvedURL.String
+
+	item.Request = &Request{
+		ID:     0,
+		Method: item.Method,
+		URL:    item.URL,
+		Name:   item.URL,
+	}
+	applyRequestConfig(item.Request, item.Config)
+	return item, nil
+}
+
+// HistoryFilter 历史列表的筛选条件，零值字段表示不限
+type HistoryFilter struct {
+	Cursor        int64     // 上一页最后一条记录的 ID，只返回更早的记录
+	Limit         int       // 每页条数，默认 DefaultHistoryPageSize
+	Methods       []string  // 请求方法 (任一)
+	StatusClasses []int     // 状态码类别 (任一)，例如 2 表示 2xx，0 表示请求失败未收到响应
+	URL           string    // URL 子串，匹配实际发送的 URL 或替换前的模板
+	From, To      time.Time // 创建时间范围 [From, To)
+	EnvironmentID int64
+	Query         string // 在 URL (实际发送的与模板)、请求体与响应体中全文搜索
+}
+
+// HistoryPage 一页历史记录，NextCursor 为 0 表示没有更多
+type HistoryPage struct {
+	Items      []*HistoryItem
+	NextCursor int64
+}
+
+// ListHistory 按筛选条件获取历史记录 (按 ID 即时间倒序)，不包含完整响应
+func ListHistory(f HistoryFilter) (*HistoryPage, error) {
+	limit := f.Limit
+	if limit <= 0 {
+		limit = DefaultHistoryPageSize
+	}
+	if limit > MaxHistoryPageSize {
+		limit = MaxHistoryPageSize
+	}
+
+	var (
+		where []string
+		args  []interface{}
+	)
+	if f.Cursor > 0 {
+		where = append(where, "id < ?")
+		args = append(args, f.Cursor)
+	}
+	if len(f.Methods) > 0 {
+		where = append(where, "method IN ("+placeholders(len(f.Methods))+")")
+		for _, m := range f.Methods {
+			args = append(args, strings.ToUpper(m))
+		}
+	}
+	if len(f.StatusClasses) > 0 {
+		where = append(where, "status_code / 100 IN ("+placeholders(len(f.StatusClasses))+")")
+		for _, c := range f.StatusClasses {
+			args = append(args, c)
+		}
+	}
+	if f.URL != "" {
+		like := "%" + escapeLike(f.URL) + "%"
+		where = append(where, "(resolved_url LIKE ? ESCAPE '\\' OR url LIKE ? ESCAPE '\\')")
+		args = append(args, like, like)
+	}
+	// created_at 为 UTC 的 "YYYY-MM-DD HH:MM:SS" 文本
+	if !f.From.IsZero() {
+		where = append(where, "created_at >= ?")
+		args = append(args, f.From.UTC().Format(time.DateTime))
+	}
+	if !f.To.IsZero() {
+		where = append(where, "created_at < ?")
+		args = append(args, f.To.UTC().Format(time.DateTime))
+	}
+	if f.EnvironmentID != 0 {
+		where = append(where, "environment_id = ?")
+		args = append(args, f.EnvironmentID)
+	}
+	if q := strings.TrimSpace(f.Query); q != "" {
+		cond, qargs := historySearchCondition(q)
+		where = append(where, "id IN (SELECT rowid FROM history_fts WHERE "+cond+")")
+		args = append(args, qargs...)
+	}
+
+	query := `SELECT ` + historyColumns + ` FROM history`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit+1)
+
+	rows, err := DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var list []*HistoryItem
+	page := &HistoryPage{Items: []*HistoryItem{}}
 	for rows.Next() {
-		item := &HistoryItem{}
-		if err := rows.Scan(&item.ID, &item.Method, &item.URL, &item.Config, &item.CreatedAt); err != nil {
+		item, err := scanHistoryItem(rows)
+		if err != nil {
 			return nil, err
 		}
+		page.Items = append(page.Items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(page.Items) > limit {
+		page.Items = page.Items[:limit]
+		page.NextCursor = page.Items[limit-1].ID
+	}
+	return page, nil
+}
 
-		item.Request = &Request{
-			ID:     0,
-			Method: item.Method,
-			URL:    item.URL,
-			Name:   item.URL,
-		}
+// historySearchCondition 全文搜索条件
+// trigram 分词要求至少 3 个字符，更短的关键词退化为 LIKE 扫描
+func historySearchCondition(q string) (string, []interface{}) {
+	if utf8.RuneCountInString(q) >= 3 {
+		// 整体作为短语匹配，避免关键词中的 FTS5 语法字符被解释
+		return "history_fts MATCH ?", []interface{}{`"` + strings.ReplaceAll(q, `"`, `""`) + `"`}
+	}
+	like := "%" + escapeLike(q) + "%"
+	return `url LIKE ? ESCAPE '\' OR request_body LIKE ? ESCAPE '\' OR response_body LIKE ? ESCAPE '\'`,
+		[]interface{}{like, like, like}
+}
 
-		var configData struct {
-			Params  []KeyValue `json:"params"`
-			Headers []KeyValue `json:"headers"`
-			Auth    AuthConfig `json:"auth"`
-			Body    BodyConfig `json:"body"`
-		}
-		if item.Config != "" {
-			_ = json.Unmarshal([]byte(item.Config), &configData)
-		}
-		item.Request.Params = configData.Params
-		item.Request.Headers = configData.Headers
-		item.Request.Auth = configData.Auth
-		item.Request.Body = configData.Body
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
 
-		list = append(list, item)
+// escapeLike 转义 LIKE 中的通配符 (配合 ESCAPE '\')
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// GetHistoryItem 获取单条历史记录及其实际发送的请求与完整响应，记录不存在时返回 sql.ErrNoRows
+func GetHistoryItem(id int64) (*HistoryItem, error) {
+	var (
+		blob     []byte
+		sentJSON sql.NullString
+	)
+	row := DB.QueryRow(`SELECT `+historyColumns+`, sent_config, response FROM history WHERE id = ?`, id)
+	item, err := scanHistoryItem(row, &sentJSON, &blob)
+	if err != nil {
+		return nil, err
+	}
+	if sentJSON.Valid {
+		item.Sent = &Request{Method: item.Method, URL: item.ResolvedURL, Name: item.ResolvedURL}
+		applyRequestConfig(item.Sent, sentJSON.String)
+	}
+	if len(blob) > 0 {
+		if item.Response, err = decompressHistoryResponse(blob); err != nil {
+			return nil, fmt.Errorf("解析历史响应失败: %v", err)
+		}
 	}
-	return list, nil
+	return item, nil
 }
 
 // [新增] DeleteHistoryItem 删除单条历史
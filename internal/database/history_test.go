@@ -0,0 +1,39 @@
+package database
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestCompressHistoryResponseTruncation(t *testing.T) {
+	// 截断位置落在 3 字节的汉字中间
+	body := strings.Repeat("a", MaxHistoryBodySize-1) + "中文"
+	blob, err := compressHistoryResponse(HistoryResponse{StatusCode: 200, Body: body})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := decompressHistoryResponse(blob)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !resp.Truncated || !utf8.ValidString(resp.Body) || resp.Body != body[:MaxHistoryBodySize-1] {
+		t.Errorf("truncated = %v, len = %d, valid = %v", resp.Truncated, len(resp.Body), utf8.ValidString(resp.Body))
+	}
+
+	binary := strings.Repeat("QUJD", MaxHistoryBodySize/4+1)
+	if blob, err = compressHistoryResponse(HistoryResponse{Body: binary, IsBinary: true}); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = decompressHistoryResponse(blob); err != nil || len(resp.Body)%4 != 0 || len(resp.Body) > MaxHistoryBodySize {
+		t.Errorf("binary body length = %d, %v", len(resp.Body), err)
+	}
+
+	small := HistoryResponse{Body: "中文"}
+	if blob, err = compressHistoryResponse(small); err != nil {
+		t.Fatal(err)
+	}
+	if resp, err = decompressHistoryResponse(blob); err != nil || resp.Truncated || resp.Body != small.Body {
+		t.Errorf("small body = %+v, %v", resp, err)
+	}
+}
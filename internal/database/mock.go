@@ -1,33 +1,169 @@
 package database
 
 import (
+	"database/sql"
 	"encoding/json"
 	"fmt"
 )
 
 // MockRule 对应数据库 mock_rules 表
 type MockRule struct {
-	ID              int64             `json:"id"`
-	PathPattern     string            `json:"path_pattern"`     // 匹配路径，例如 /users/123
+	ID int64 `json:"id"`
+	// PathPattern 匹配路径，支持:
+	//   /users/123              精确匹配
+	//   /users/:id、/users/{id}  路径参数 (匹配一段)
+	//   /files/*、/static/**     通配符，* 匹配一段，** 匹配零或多段
+	//   ~^/v\d+/orders$         以 ~ 开头为正则 (完整匹配路径，命名分组作为参数)
+	PathPattern     string            `json:"path_pattern"`
 	Method          string            `json:"method"`           // HTTP 方法
 	ResponseBody    string            `json:"response_body"`    // 模拟返回的 Body
 	ResponseHeaders map[string]string `json:"response_headers"` // 模拟返回的 Headers
 	StatusCode      int               `json:"status_code"`      // 模拟状态码
 	IsActive        bool              `json:"is_active"`        // 开关
+
+	// Responses 条件响应，按顺序评估，第一个条件全部满足的变体生效
+	// 均不满足时使用上面的默认响应 (StatusCode、ResponseHeaders、ResponseBody)
+	Responses []MockResponse `json:"responses,omitempty"`
+
+	// 响应延迟: 每次等待 DelayMs 再加上 [0, JitterMs] 的随机时间
+	DelayMs  int `json:"delay_ms,omitempty"`
+	JitterMs int `json:"jitter_ms,omitempty"`
+
+	// Fault 故障注入，为 nil 时正常响应
+	Fault *MockFault `json:"fault,omitempty"`
+
+	// 场景 (状态机): 同一 Scenario 的规则共享当前状态，初始状态为 Started
+	// RequiredState 非空时只在场景处于该状态时匹配，NewState 非空时响应后切换到该状态
+	Scenario      string `json:"scenario,omitempty"`
+	RequiredState string `json:"required_state,omitempty"`
+	NewState      string `json:"new_state,omitempty"`
+
+	// Template 为 true 时响应体与响应头按 Go 模板渲染，否则原样返回
+	Template bool `json:"template,omitempty"`
+}
+
+// 故障类型
+const (
+	MockFaultError    = "error"    // 返回 StatusCode (默认 500) 与 Body
+	MockFaultReset    = "reset"    // 不返回任何内容，直接重置连接
+	MockFaultTruncate = "truncate" // 按完整长度声明 Content-Length，只写入 TruncateBytes (默认一半) 后断开
+	MockFaultTrickle  = "trickle"  // 每隔 ChunkDelayMs 写入 ChunkBytes，缓慢地输出响应体
+	MockFaultTimeout  = "timeout"  // 永不响应，直到客户端断开
+)
+
+// MockFault 规则的故障注入配置
+type MockFault struct {
+	Type        string  `json:"type"`
+	Probability float64 `json:"probability,omitempty"` // 触发概率 (0-1]，0 表示每次都触发
+
+	StatusCode    int    `json:"status_code,omitempty"`    // error
+	Body          string `json:"body,omitempty"`           // error
+	TruncateBytes int    `json:"truncate_bytes,omitempty"` // truncate
+	ChunkBytes    int    `json:"chunk_bytes,omitempty"`    // trickle，默认 1
+	ChunkDelayMs  int    `json:"chunk_delay_ms,omitempty"` // trickle，默认 100
+}
+
+// MockResponse 规则的一个响应变体
+type MockResponse struct {
+	Name       string            `json:"name,omitempty"`
+	Conditions []MockCondition   `json:"conditions"`        // 全部满足时命中，为空时总是命中
+	StatusCode int               `json:"status_code"`       // 为 0 时沿用规则的状态码
+	Headers    map[string]string `json:"headers,omitempty"` // 与规则的响应头合并，同名时覆盖
+	Body       string            `json:"body"`
+}
+
+// 条件的取值来源
+const (
+	MockMatchQuery     = "query"      // Key: 查询参数名
+	MockMatchHeader    = "header"     // Key: 请求头名
+	MockMatchJSONPath  = "json_path"  // Key: 请求体上的 JSON Path
+	MockMatchBodyRegex = "body_regex" // Value: 匹配整个请求体的正则 (忽略 Operator)
+)
+
+// 条件的比较方式
+const (
+	MockOpEquals    = "equals" // 默认
+	MockOpNotEquals = "not_equals"
+	MockOpContains  = "contains"
+	MockOpMatches   = "matches" // Value 为正则
+	MockOpExists    = "exists"
+	MockOpNotExists = "not_exists"
+)
+
+// MockCondition 响应变体的匹配条件
+type MockCondition struct {
+	Source   string `json:"source"`
+	Key      string `json:"key,omitempty"`
+	Operator string `json:"operator,omitempty"`
+	Value    string `json:"value,omitempty"`
+}
+
+const mockRuleColumns = `id, path_pattern, method, response_body, response_headers, status_code, is_active, responses, delay_ms, jitter_ms, fault,
+	scenario, required_state, new_state, template`
+
+func scanMockRule(row rowScanner) (*MockRule, error) {
+	var r MockRule
+	var headersStr string
+	var responses, fault, scenario, requiredState, newState sql.NullString
+	var delay, jitter, tmpl sql.NullInt64
+	if err := row.Scan(&r.ID, &r.PathPattern, &r.Method, &r.ResponseBody, &headersStr, &r.StatusCode, &r.IsActive,
+		&responses, &delay, &jitter, &fault, &scenario, &requiredState, &newState, &tmpl); err != nil {
+		return nil, err
+	}
+	r.DelayMs, r.JitterMs, r.Template = int(delay.Int64), int(jitter.Int64), tmpl.Int64 != 0
+	r.Scenario, r.RequiredState, r.NewState = scenario.String, requiredState.String, newState.String
+
+	if headersStr != "" {
+		_ = json.Unmarshal([]byte(headersStr), &r.ResponseHeaders)
+	}
+	if r.ResponseHeaders == nil {
+		r.ResponseHeaders = make(map[string]string)
+	}
+	if responses.String != "" {
+		_ = json.Unmarshal([]byte(responses.String), &r.Responses)
+	}
+	if fault.String != "" {
+		r.Fault = &MockFault{}
+		if json.Unmarshal([]byte(fault.String), r.Fault) != nil {
+			r.Fault = nil
+		}
+	}
+	return &r, nil
+}
+
+// marshalMockRule 序列化规则中以 JSON 保存的列
+func marshalMockRule(rule *MockRule) (headers string, responses, fault interface{}, err error) {
+	headersJSON, err := json.Marshal(rule.ResponseHeaders)
+	if err != nil {
+		return "", nil, nil, fmt.Errorf("marshal headers failed: %v", err)
+	}
+	if len(rule.Responses) > 0 {
+		b, err := json.Marshal(rule.Responses)
+		if err != nil {
+			return "", nil, nil, fmt.Errorf("marshal responses failed: %v", err)
+		}
+		responses = string(b)
+	}
+	if fault, err = marshalNullable(rule.Fault); err != nil {
+		return "", nil, nil, err
+	}
+	return string(headersJSON), responses, fault, nil
 }
 
 // CreateMockRule 创建规则
 func CreateMockRule(rule *MockRule) (int64, error) {
-	headersJSON, err := json.Marshal(rule.ResponseHeaders)
+	headersJSON, responses, fault, err := marshalMockRule(rule)
 	if err != nil {
-		return 0, fmt.Errorf("marshal headers failed: %v", err)
+		return 0, err
 	}
 
 	query := `
-		INSERT INTO mock_rules (path_pattern, method, response_body, response_headers, status_code, is_active)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO mock_rules (path_pattern, method, response_body, response_headers, status_code, is_active, responses, delay_ms, jitter_ms, fault,
+			scenario, required_state, new_state, template)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	result, err := DB.Exec(query, rule.PathPattern, rule.Method, rule.ResponseBody, string(headersJSON), rule.StatusCode, rule.IsActive)
+	result, err := DB.Exec(query, rule.PathPattern, rule.Method, rule.ResponseBody, headersJSON, rule.StatusCode, rule.IsActive,
+		responses, rule.DelayMs, rule.JitterMs, fault, rule.Scenario, rule.RequiredState, rule.NewState, rule.Template)
 	if err != nil {
 		return 0, err
 	}
@@ -36,41 +172,26 @@ func CreateMockRule(rule *MockRule) (int64, error) {
 
 // UpdateMockRule 更新规则
 func UpdateMockRule(rule *MockRule) error {
-	headersJSON, err := json.Marshal(rule.ResponseHeaders)
+	headersJSON, responses, fault, err := marshalMockRule(rule)
 	if err != nil {
-		return fmt.Errorf("marshal headers failed: %v", err)
+		return err
 	}
 
 	query := `
 		UPDATE mock_rules 
-		SET path_pattern=?, method=?, response_body=?, response_headers=?, status_code=?, is_active=?
+		SET path_pattern=?, method=?, response_body=?, response_headers=?, status_code=?, is_active=?, responses=?,
+			delay_ms=?, jitter_ms=?, fault=?, scenario=?, required_state=?, new_state=?, template=?
 		WHERE id=?
 	`
-	_, err = DB.Exec(query, rule.PathPattern, rule.Method, rule.ResponseBody, string(headersJSON), rule.StatusCode, rule.IsActive, rule.ID)
+	_, err = DB.Exec(query, rule.PathPattern, rule.Method, rule.ResponseBody, headersJSON, rule.StatusCode, rule.IsActive,
+		responses, rule.DelayMs, rule.JitterMs, fault, rule.Scenario, rule.RequiredState, rule.NewState, rule.Template, rule.ID)
 	return err
 }
 
 // GetMockRule 获取单个规则
 func GetMockRule(id int64) (*MockRule, error) {
-	query := `SELECT id, path_pattern, method, response_body, response_headers, status_code, is_active FROM mock_rules WHERE id = ?`
-	row := DB.QueryRow(query, id)
-
-	var r MockRule
-	var headersStr string
-	
-	err := row.Scan(&r.ID, &r.PathPattern, &r.Method, &r.ResponseBody, &headersStr, &r.StatusCode, &r.IsActive)
-	if err != nil {
-		return nil, err
-	}
-
-	if headersStr != "" {
-		_ = json.Unmarshal([]byte(headersStr), &r.ResponseHeaders)
-	}
-	if r.ResponseHeaders == nil {
-		r.ResponseHeaders = make(map[string]string)
-	}
-
-	return &r, nil
+	query := `SELECT ` + mockRuleColumns + ` FROM mock_rules WHERE id = ?`
+	return scanMockRule(DB.QueryRow(query, id))
 }
 
 // DeleteMockRule 删除规则
@@ -81,29 +202,48 @@ func DeleteMockRule(id int64) error {
 
 // GetAllMockRules 获取所有规则
 func GetAllMockRules() ([]*MockRule, error) {
-	query := `SELECT id, path_pattern, method, response_body, response_headers, status_code, is_active FROM mock_rules ORDER BY id DESC`
-	rows, err := DB.Query(query)
+	return queryMockRules(`SELECT ` + mockRuleColumns + ` FROM mock_rules ORDER BY id DESC`)
+}
+
+// GetActiveMockRules 获取指定方法下所有启用的规则 (按 ID 升序)
+func GetActiveMockRules(method string) ([]*MockRule, error) {
+	return queryMockRules(`SELECT `+mockRuleColumns+` FROM mock_rules WHERE method = ? AND is_active = 1 ORDER BY id`, method)
+}
+
+// GetMockScenarioNames 获取规则中使用的场景名称及各场景的规则数
+func GetMockScenarioNames() (map[string]int, error) {
+	rows, err := DB.Query(`SELECT scenario, COUNT(*) FROM mock_rules WHERE scenario IS NOT NULL AND scenario != '' GROUP BY scenario`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var list []*MockRule
+	names := make(map[string]int)
 	for rows.Next() {
-		var r MockRule
-		var headersStr string
-		if err := rows.Scan(&r.ID, &r.PathPattern, &r.Method, &r.ResponseBody, &headersStr, &r.StatusCode, &r.IsActive); err != nil {
+		var name string
+		var count int
+		if err := rows.Scan(&name, &count); err != nil {
 			return nil, err
 		}
-		
-		if headersStr != "" {
-			_ = json.Unmarshal([]byte(headersStr), &r.ResponseHeaders)
-		}
-		if r.ResponseHeaders == nil {
-			r.ResponseHeaders = make(map[string]string)
-		}
+		names[name] = count
+	}
+	return names, rows.Err()
+}
 
-		list = append(list, &r)
+func queryMockRules(query string, args ...interface{}) ([]*MockRule, error) {
+	rows, err := DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*MockRule
+	for rows.Next() {
+		r, err := scanMockRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, r)
 	}
-	return list, nil
-}
\ No newline at end of file
+	return list, rows.Err()
+}
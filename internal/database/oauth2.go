@@ -0,0 +1,89 @@
+package database
+
+import (
+	"database/sql"
+	"time"
+)
+
+// OAuth2Token 对应数据库 oauth2_tokens 表
+type OAuth2Token struct {
+	ID           int64      `json:"id"`
+	CacheKey     string     `json:"cache_key"`
+	GrantType    string     `json:"grant_type"`
+	TokenURL     string     `json:"token_url"`
+	ClientID     string     `json:"client_id"`
+	Scope        string     `json:"scope"`
+	AccessToken  string     `json:"-"`
+	RefreshToken string     `json:"-"`
+	TokenType    string     `json:"token_type"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ValidAt 判断令牌在 t 时刻是否仍然有效
+func (t *OAuth2Token) ValidAt(at time.Time) bool {
+	return t.AccessToken != "" && (t.ExpiresAt == nil || t.ExpiresAt.After(at))
+}
+
+// SaveOAuth2Token 新增或覆盖令牌 (以 cache_key 唯一确定)
+func SaveOAuth2Token(t *OAuth2Token) error {
+	t.UpdatedAt = time.Now().UTC()
+	query := `
+		INSERT INTO oauth2_tokens (cache_key, grant_type, token_url, client_id, scope, access_token, refresh_token, token_type, expires_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(cache_key) DO UPDATE SET
+			grant_type=excluded.grant_type, token_url=excluded.token_url, client_id=excluded.client_id, scope=excluded.scope,
+			access_token=excluded.access_token, refresh_token=excluded.refresh_token, token_type=excluded.token_type,
+			expires_at=excluded.expires_at, updated_at=excluded.updated_at
+	`
+	_, err := DB.Exec(query, t.CacheKey, t.GrantType, t.TokenURL, t.ClientID, t.Scope,
+		t.AccessToken, t.RefreshToken, t.TokenType, nullTime(t.ExpiresAt), t.UpdatedAt)
+	return err
+}
+
+const oauth2Columns = `id, cache_key, COALESCE(grant_type, ''), COALESCE(token_url, ''), COALESCE(client_id, ''), COALESCE(scope, ''),
+	COALESCE(access_token, ''), COALESCE(refresh_token, ''), COALESCE(token_type, ''), expires_at, updated_at`
+
+// GetOAuth2Token 按 cache_key 获取令牌，不存在时返回 sql.ErrNoRows
+func GetOAuth2Token(cacheKey string) (*OAuth2Token, error) {
+	return scanOAuth2Token(DB.QueryRow(`SELECT `+oauth2Columns+` FROM oauth2_tokens WHERE cache_key = ?`, cacheKey))
+}
+
+// GetAllOAuth2Tokens 获取所有缓存的令牌
+func GetAllOAuth2Tokens() ([]*OAuth2Token, error) {
+	rows, err := DB.Query(`SELECT ` + oauth2Columns + ` FROM oauth2_tokens ORDER BY id ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []*OAuth2Token
+	for rows.Next() {
+		t, err := scanOAuth2Token(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, t)
+	}
+	return list, rows.Err()
+}
+
+// DeleteOAuth2Token 删除令牌 (下次发送时重新获取)
+func DeleteOAuth2Token(id int64) error {
+	_, err := DB.Exec("DELETE FROM oauth2_tokens WHERE id = ?", id)
+	return err
+}
+
+func scanOAuth2Token(row rowScanner) (*OAuth2Token, error) {
+	var t OAuth2Token
+	var expires sql.NullTime
+	if err := row.Scan(&t.ID, &t.CacheKey, &t.GrantType, &t.TokenURL, &t.ClientID, &t.Scope,
+		&t.AccessToken, &t.RefreshToken, &t.TokenType, &expires, &t.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if expires.Valid {
+		e := expires.Time
+		t.ExpiresAt = &e
+	}
+	return &t, nil
+}
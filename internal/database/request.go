@@ -14,6 +14,7 @@ type KeyValue struct {
 	Description string `json:"description"`
 	Enabled     bool   `json:"enabled"`
 	Type        string `json:"type,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
 }
 
 type AuthConfig struct {
@@ -21,6 +22,10 @@ type AuthConfig struct {
 	Basic  map[string]string `json:"basic,omitempty"`
 	Bearer map[string]string `json:"bearer,omitempty"`
 	APIKey map[string]string `json:"apikey,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+	HMAC   map[string]string `json:"hmac,omitempty"`
+	OAuth2 map[string]string `json:"oauth2,omitempty"`
+	AWSV4  map[string]string `json:"awsv4,omitempty"`
 }
 
 type BodyConfig struct {
@@ -32,20 +37,125 @@ type BodyConfig struct {
 	BinaryPath   string     `json:"binary_path,omitempty"`
 	GraphQLQuery string     `json:"graphql_query,omitempty"`
 	GraphQLVars  string     `json:"graphql_vars,omitempty"`
+
+	GraphQLOperation string `json:"graphql_operation,omitempty"`
+}
+
+// 断言类型
+const (
+	AssertStatusEquals      = "status_equals"       // Expected: 状态码
+	AssertHeaderPresent     = "header_present"      // Target: Header 名
+	AssertJSONPathEquals    = "json_path_equals"    // Target: JSON Path, Expected: 期望值
+	AssertJSONPathMatches   = "json_path_matches"   // Target: JSON Path, Expected: 正则
+	AssertResponseTimeBelow = "response_time_below" // Expected: 毫秒数
+	AssertBodyContains      = "body_contains"       // Expected: 子串
+	AssertScript            = "script"              // 脚本中 test() 的结果，Target: 测试名 (由脚本生成，不可手动配置)
+)
+
+// Assertion 响应断言规则，保存在 config 中，由 proxy 在收到响应后评估
+type Assertion struct {
+	Type     string `json:"type"`
+	Target   string `json:"target,omitempty"`
+	Expected string `json:"expected,omitempty"`
+	Enabled  bool   `json:"enabled"`
+}
+
+// 提取器类型
+const (
+	ExtractJSONPath = "json_path" // Expression: JSON Path
+	ExtractRegex    = "regex"     // Expression: 正则，有捕获组时取第一个捕获组
+	ExtractHeader   = "header"    // Expression: Header 名
+	ExtractCookie   = "cookie"    // Expression: Cookie 名
+)
+
+// 提取结果写入的作用域
+const (
+	ScopeRuntime     = "runtime"     // 运行时变量，仅在本次运行 (或本次会话) 内有效
+	ScopeEnvironment = "environment" // 写回当前使用的环境并持久化
+)
+
+// Extractor 从响应中提取值并写入变量，用于请求串联
+type Extractor struct {
+	Type       string `json:"type"`
+	Expression string `json:"expression"`
+	Variable   string `json:"variable"`
+	Scope      string `json:"scope,omitempty"` // 默认为 runtime
+	Enabled    bool   `json:"enabled"`
+}
+
+// Scripts 请求前与响应后脚本 (JavaScript)，可保存在请求与分组上
+type Scripts struct {
+	PreRequest   string `json:"pre_request,omitempty"`
+	PostResponse string `json:"post_response,omitempty"`
 }
 
 type Request struct {
-	ID           int64      `json:"id"`
-	CollectionID int64      `json:"collection_id"`
-	Name         string     `json:"name"`
-	Method       string     `json:"method"`
-	URL          string     `json:"url"`
-	Params       []KeyValue `json:"params"`
-	Headers      []KeyValue `json:"headers"`
-	Auth         AuthConfig `json:"auth"`
-	Body         BodyConfig `json:"body"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    time.Time  `json:"updated_at"`
+	ID           int64          `json:"id"`
+	CollectionID int64          `json:"collection_id"`
+	Name         string         `json:"name"`
+	Method       string         `json:"method"`
+	URL          string         `json:"url"`
+	Params       []KeyValue     `json:"params"`
+	Headers      []KeyValue     `json:"headers"`
+	Auth         AuthConfig     `json:"auth"`
+	Body         BodyConfig     `json:"body"`
+	Variables    []KeyValue     `json:"variables,omitempty"` // 请求级变量，优先级最高
+	Assertions   []Assertion    `json:"assertions,omitempty"`
+	Extractors   []Extractor    `json:"extractors,omitempty"`
+	Settings     ClientSettings `json:"settings"` // 客户端设置，零值字段沿用全局设置
+	Scripts      Scripts        `json:"scripts"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// requestConfig 对应 requests / history 表中 config 列的 JSON 结构
+type requestConfig struct {
+	Params     []KeyValue     `json:"params"`
+	Headers    []KeyValue     `json:"headers"`
+	Auth       AuthConfig     `json:"auth"`
+	Body       BodyConfig     `json:"body"`
+	Variables  []KeyValue     `json:"variables,omitempty"`
+	Assertions []Assertion    `json:"assertions,omitempty"`
+	Extractors []Extractor    `json:"extractors,omitempty"`
+	Settings   ClientSettings `json:"settings"`
+	Scripts    Scripts        `json:"scripts"`
+}
+
+// marshalRequestConfig 将请求的配置部分序列化为 config 列的内容
+func marshalRequestConfig(req *Request) (string, error) {
+	configData := requestConfig{
+		Params:     req.Params,
+		Headers:    req.Headers,
+		Auth:       req.Auth,
+		Body:       req.Body,
+		Variables:  req.Variables,
+		Assertions: req.Assertions,
+		Extractors: req.Extractors,
+		Settings:   req.Settings,
+		Scripts:    req.Scripts,
+	}
+	configJSON, err := json.Marshal(configData)
+	if err != nil {
+		return "", fmt.Errorf("marshal config failed: %v", err)
+	}
+	return string(configJSON), nil
+}
+
+// applyRequestConfig 解析 config 列并填充到请求中，解析失败时忽略
+func applyRequestConfig(req *Request, config string) {
+	var configData requestConfig
+	if config != "" {
+		_ = json.Unmarshal([]byte(config), &configData)
+	}
+	req.Params = configData.Params
+	req.Headers = configData.Headers
+	req.Auth = configData.Auth
+	req.Body = configData.Body
+	req.Variables = configData.Variables
+	req.Assertions = configData.Assertions
+	req.Extractors = configData.Extractors
+	req.Settings = configData.Settings
+	req.Scripts = configData.Scripts
 }
 
 type requestDBModel struct {
@@ -61,15 +171,9 @@ type requestDBModel struct {
 
 // CreateRequest 创建新请求
 func CreateRequest(req *Request) (int64, error) {
-	configData := map[string]interface{}{
-		"params":  req.Params,
-		"headers": req.Headers,
-		"auth":    req.Auth,
-		"body":    req.Body,
-	}
-	configJSON, err := json.Marshal(configData)
+	configJSON, err := marshalRequestConfig(req)
 	if err != nil {
-		return 0, fmt.Errorf("marshal config failed: %v", err)
+		return 0, err
 	}
 
 	// [修复] 处理 CollectionID: 如果是 0，存为 NULL
@@ -81,8 +185,8 @@ func CreateRequest(req *Request) (int64, error) {
 	}
 
 	query := `INSERT INTO requests (collection_id, name, method, url, config, updated_at) VALUES (?, ?, ?, ?, ?, CURRENT_TIMESTAMP)`
-	
-	result, err := DB.Exec(query, colID, req.Name, req.Method, req.URL, string(configJSON))
+
+	result, err := DB.Exec(query, colID, req.Name, req.Method, req.URL, configJSON)
 	if err != nil {
 		return 0, err
 	}
@@ -91,13 +195,10 @@ func CreateRequest(req *Request) (int64, error) {
 
 // UpdateRequest 更新现有请求
 func UpdateRequest(req *Request) error {
-	configData := map[string]interface{}{
-		"params":  req.Params,
-		"headers": req.Headers,
-		"auth":    req.Auth,
-		"body":    req.Body,
+	configJSON, err := marshalRequestConfig(req)
+	if err != nil {
+		return err
 	}
-	configJSON, _ := json.Marshal(configData)
 
 	// [修复] 处理 CollectionID
 	var colID interface{}
@@ -112,51 +213,14 @@ func UpdateRequest(req *Request) error {
 		SET collection_id=?, name=?, method=?, url=?, config=?, updated_at=CURRENT_TIMESTAMP
 		WHERE id=?
 	`
-	_, err := DB.Exec(query, colID, req.Name, req.Method, req.URL, string(configJSON), req.ID)
+	_, err = DB.Exec(query, colID, req.Name, req.Method, req.URL, configJSON, req.ID)
 	return err
 }
 
 // GetRequest 获取单个请求详情
 func GetRequest(id int64) (*Request, error) {
 	query := `SELECT id, collection_id, name, method, url, config, created_at, updated_at FROM requests WHERE id = ?`
-	row := DB.QueryRow(query, id)
-
-	var dbReq requestDBModel
-	err := row.Scan(&dbReq.ID, &dbReq.CollectionID, &dbReq.Name, &dbReq.Method, &dbReq.URL, &dbReq.Config, &dbReq.CreatedAt, &dbReq.UpdatedAt)
-	if err != nil {
-		return nil, err
-	}
-
-	req := &Request{
-		ID:           dbReq.ID,
-		// [修复] 如果 DB 里是 NULL，转回 0 给前端
-		CollectionID: 0, 
-		Name:         dbReq.Name,
-		Method:       dbReq.Method,
-		URL:          dbReq.URL,
-		CreatedAt:    dbReq.CreatedAt,
-		UpdatedAt:    dbReq.UpdatedAt,
-	}
-	if dbReq.CollectionID.Valid {
-		req.CollectionID = dbReq.CollectionID.Int64
-	}
-
-	var configData struct {
-		Params  []KeyValue `json:"params"`
-		Headers []KeyValue `json:"headers"`
-		Auth    AuthConfig `json:"auth"`
-		Body    BodyConfig `json:"body"`
-	}
-	if dbReq.Config != "" {
-		_ = json.Unmarshal([]byte(dbReq.Config), &configData)
-	}
-	
-	req.Params = configData.Params
-	req.Headers = configData.Headers
-	req.Auth = configData.Auth
-	req.Body = configData.Body
-
-	return req, nil
+	return scanRequest(DB.QueryRow(query, id))
 }
 
 // DeleteRequest 保持不变
@@ -168,7 +232,17 @@ func DeleteRequest(id int64) error {
 // GetAllRequests 获取所有请求
 func GetAllRequests() ([]*Request, error) {
 	query := `SELECT id, collection_id, name, method, url, config, created_at, updated_at FROM requests ORDER BY updated_at DESC`
-	rows, err := DB.Query(query)
+	return queryRequests(query)
+}
+
+// GetRequestsByCollection 获取分组下的直属请求 (按创建顺序，用于集合运行)
+func GetRequestsByCollection(collectionID int64) ([]*Request, error) {
+	query := `SELECT id, collection_id, name, method, url, config, created_at, updated_at FROM requests WHERE collection_id = ? ORDER BY id ASC`
+	return queryRequests(query, collectionID)
+}
+
+func queryRequests(query string, args ...interface{}) ([]*Request, error) {
+	rows, err := DB.Query(query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -176,39 +250,37 @@ func GetAllRequests() ([]*Request, error) {
 
 	var list []*Request
 	for rows.Next() {
-		var dbReq requestDBModel
-		if err := rows.Scan(&dbReq.ID, &dbReq.CollectionID, &dbReq.Name, &dbReq.Method, &dbReq.URL, &dbReq.Config, &dbReq.CreatedAt, &dbReq.UpdatedAt); err != nil {
+		req, err := scanRequest(rows)
+		if err != nil {
 			return nil, err
 		}
-
-		req := &Request{
-			ID:           dbReq.ID,
-			CollectionID: 0,
-			Name:         dbReq.Name,
-			Method:       dbReq.Method,
-			URL:          dbReq.URL,
-			CreatedAt:    dbReq.CreatedAt,
-			UpdatedAt:    dbReq.UpdatedAt,
-		}
-		if dbReq.CollectionID.Valid {
-			req.CollectionID = dbReq.CollectionID.Int64
-		}
-		
-		var configData struct {
-			Params  []KeyValue `json:"params"`
-			Headers []KeyValue `json:"headers"`
-			Auth    AuthConfig `json:"auth"`
-			Body    BodyConfig `json:"body"`
-		}
-		if dbReq.Config != "" {
-			_ = json.Unmarshal([]byte(dbReq.Config), &configData)
-		}
-		req.Params = configData.Params
-		req.Headers = configData.Headers
-		req.Auth = configData.Auth
-		req.Body = configData.Body
-
 		list = append(list, req)
 	}
 	return list, nil
-}
\ No newline at end of file
+}
+
+func scanRequest(row rowScanner) (*Request, error) {
+	var dbReq requestDBModel
+	err := row.Scan(&dbReq.ID, &dbReq.CollectionID, &dbReq.Name, &dbReq.Method, &dbReq.URL, &dbReq.Config, &dbReq.CreatedAt, &dbReq.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &Request{
+		ID: dbReq.ID,
+		// [修复] 如果 DB 里是 NULL，转回 0 给前端
+		CollectionID: 0,
+		Name:         dbReq.Name,
+		Method:       dbReq.Method,
+		URL:          dbReq.URL,
+		CreatedAt:    dbReq.CreatedAt,
+		UpdatedAt:    dbReq.UpdatedAt,
+	}
+	if dbReq.CollectionID.Valid {
+		req.CollectionID = dbReq.CollectionID.Int64
+	}
+
+	applyRequestConfig(req, dbReq.Config)
+
+	return req, nil
+}
@@ -0,0 +1,144 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// 设置项键名
+const (
+	SettingGlobalVariables = "global_variables"
+	SettingClientSettings  = "client_settings"
+	SettingProxy           = "proxy"
+)
+
+// GetSetting 读取设置项并反序列化到 v，设置不存在时保持 v 不变
+func GetSetting(key string, v interface{}) error {
+	var value string
+	err := DB.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if value == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(value), v)
+}
+
+// SaveSetting 序列化 v 并写入设置项 (存在则覆盖)
+func SaveSetting(key string, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("marshal setting failed: %v", err)
+	}
+	query := `INSERT INTO settings (key, value) VALUES (?, ?) ON CONFLICT(key) DO UPDATE SET value = excluded.value`
+	_, err = DB.Exec(query, key, string(data))
+	return err
+}
+
+// GetGlobalVariables 获取全局变量 (优先级最低的变量作用域)
+func GetGlobalVariables() ([]KeyValue, error) {
+	vars := []KeyValue{}
+	if err := GetSetting(SettingGlobalVariables, &vars); err != nil {
+		return nil, err
+	}
+	return vars, nil
+}
+
+// SaveGlobalVariables 保存全局变量
+func SaveGlobalVariables(vars []KeyValue) error {
+	if vars == nil {
+		vars = []KeyValue{}
+	}
+	return SaveSetting(SettingGlobalVariables, vars)
+}
+
+// ClientSettings 发送请求时的客户端设置
+// 请求上的零值字段表示沿用全局设置，全局设置的零值字段表示使用内置默认值
+type ClientSettings struct {
+	TimeoutMs       int    `json:"timeout_ms,omitempty"`        // 超时时间，默认 60000
+	FollowRedirects *bool  `json:"follow_redirects,omitempty"`  // 是否跟随重定向，默认 true
+	MaxRedirects    int    `json:"max_redirects,omitempty"`     // 最大重定向次数，默认 10
+	SkipTLSVerify   *bool  `json:"skip_tls_verify,omitempty"`   // 跳过证书校验 (自签名测试环境)，默认 false
+	HTTPVersion     string `json:"http_version,omitempty"`      // "" 自动协商 | "1.1" | "2"
+	ScriptTimeoutMs int    `json:"script_timeout_ms,omitempty"` // 单个脚本的执行时间上限，默认 5000
+}
+
+// Merge 用 override 中的非零值覆盖当前设置
+func (s ClientSettings) Merge(override ClientSettings) ClientSettings {
+	if override.TimeoutMs > 0 {
+		s.TimeoutMs = override.TimeoutMs
+	}
+	if override.FollowRedirects != nil {
+		s.FollowRedirects = override.FollowRedirects
+	}
+	if override.MaxRedirects > 0 {
+		s.MaxRedirects = override.MaxRedirects
+	}
+	if override.SkipTLSVerify != nil {
+		s.SkipTLSVerify = override.SkipTLSVerify
+	}
+	if override.HTTPVersion != "" {
+		s.HTTPVersion = override.HTTPVersion
+	}
+	if override.ScriptTimeoutMs > 0 {
+		s.ScriptTimeoutMs = override.ScriptTimeoutMs
+	}
+	return s
+}
+
+// GetClientSettings 获取全局客户端设置
+func GetClientSettings() (ClientSettings, error) {
+	var s ClientSettings
+	err := GetSetting(SettingClientSettings, &s)
+	return s, err
+}
+
+// SaveClientSettings 保存全局客户端设置
+func SaveClientSettings(s ClientSettings) error {
+	return SaveSetting(SettingClientSettings, s)
+}
+
+// 上游代理模式
+const (
+	ProxyModeSystem = "system" // 读取 HTTP_PROXY / HTTPS_PROXY / NO_PROXY 环境变量 (默认)
+	ProxyModeNone   = "none"   // 直连
+	ProxyModeCustom = "custom" // 使用 URL 指定的代理
+)
+
+// ProxySettings 上游代理配置
+// 全局配置 Mode 为空等同 system，环境配置 Mode 为空表示沿用全局配置
+type ProxySettings struct {
+	Mode     string   `json:"mode,omitempty"`
+	URL      string   `json:"url,omitempty"`      // http://host:port、https://host:port 或 socks5://host:port
+	Username string   `json:"username,omitempty"` // 代理认证 (HTTP 为 Basic，SOCKS5 为用户名/密码)
+	Password string   `json:"password,omitempty"`
+	Bypass   []string `json:"bypass,omitempty"` // 直连的主机: example.com、*.internal、.corp、10.0.0.0/8、<local>
+}
+
+// GetProxySettings 获取全局代理配置
+func GetProxySettings() (ProxySettings, error) {
+	var s ProxySettings
+	err := GetSetting(SettingProxy, &s)
+	return s, err
+}
+
+// SaveProxySettings 保存全局代理配置
+func SaveProxySettings(s ProxySettings) error {
+	return SaveSetting(SettingProxy, s)
+}
+
+// VariableMap 将启用的变量转换为 map，后出现的同名变量覆盖先出现的
+func VariableMap(list []KeyValue) map[string]string {
+	vars := make(map[string]string)
+	for _, v := range list {
+		if v.Enabled && v.Key != "" {
+			vars[v.Key] = v.Value
+		}
+	}
+	return vars
+}
@@ -0,0 +1,124 @@
+package graphql
+
+import (
+	"strings"
+	"testing"
+)
+
+// testIntrospection 精简的内省结果
+const testIntrospection = `{"data": {"__schema": {
+	"queryType": {"name": "Query"},
+	"mutationType": {"name": "Mutation"},
+	"subscriptionType": null,
+	"types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [
+			{"name": "user", "args": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}],
+			 "type": {"kind": "OBJECT", "name": "User"}},
+			{"name": "users", "args": [{"name": "first", "type": {"kind": "SCALAR", "name": "Int"}, "defaultValue": "10"}],
+			 "type": {"kind": "NON_NULL", "ofType": {"kind": "LIST", "ofType": {"kind": "NON_NULL", "ofType": {"kind": "OBJECT", "name": "User"}}}}}
+		], "interfaces": []},
+		{"kind": "OBJECT", "name": "Mutation", "fields": [
+			{"name": "createUser", "args": [{"name": "input", "type": {"kind": "NON_NULL", "ofType": {"kind": "INPUT_OBJECT", "name": "NewUser"}}}],
+			 "type": {"kind": "OBJECT", "name": "User"}}
+		], "interfaces": []},
+		{"kind": "OBJECT", "name": "User", "fields": [
+			{"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+			{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}},
+			{"name": "role", "args": [], "type": {"kind": "ENUM", "name": "Role"}},
+			{"name": "login", "args": [], "type": {"kind": "SCALAR", "name": "String"}, "isDeprecated": true, "deprecationReason": "use name"}
+		], "interfaces": []},
+		{"kind": "ENUM", "name": "Role", "enumValues": [{"name": "ADMIN"}, {"name": "USER"}]},
+		{"kind": "INPUT_OBJECT", "name": "NewUser", "inputFields": [
+			{"name": "name", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "String"}}},
+			{"name": "role", "type": {"kind": "ENUM", "name": "Role"}, "defaultValue": "USER"}
+		]},
+		{"kind": "SCALAR", "name": "ID"},
+		{"kind": "SCALAR", "name": "String"},
+		{"kind": "SCALAR", "name": "Int"},
+		{"kind": "OBJECT", "name": "__Schema", "fields": []}
+	],
+	"directives": [
+		{"name": "skip", "locations": ["FIELD"], "args": [{"name": "if", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "Boolean"}}}]}
+	]
+}}}`
+
+func TestParseIntrospection(t *testing.T) {
+	schema, err := ParseIntrospection([]byte(testIntrospection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sdl := schema.SDL()
+	for _, want := range []string{
+		"schema {\n  query: Query\n  mutation: Mutation\n}",
+		"type Query {\n  user(id: ID!): User\n  users(first: Int = 10): [User!]!\n}",
+		`login: String @deprecated(reason: "use name")`,
+		"enum Role {\n  ADMIN\n  USER\n}",
+		"input NewUser {\n  name: String!\n  role: Role = USER\n}",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("SDL missing %q:\n%s", want, sdl)
+		}
+	}
+	for _, skip := range []string{"__Schema", "scalar ID", "directive @skip"} {
+		if strings.Contains(sdl, skip) {
+			t.Errorf("SDL should not contain %q", skip)
+		}
+	}
+
+	ops := schema.Operations()
+	if len(ops.Query) != 2 || ops.Query[0].Name != "user" || ops.Query[1].Type != "[User!]!" ||
+		len(ops.Mutation) != 1 || ops.Mutation[0].Args[0].Type != "NewUser!" || len(ops.Subscription) != 0 {
+		t.Errorf("Operations = %+v", ops)
+	}
+}
+
+func TestParseIntrospectionErrors(t *testing.T) {
+	for _, body := range []string{
+		`not json`,
+		`{"data": null, "errors": [{"message": "introspection disabled"}]}`,
+		`{"data": {}}`,
+		`{"__schema": {"types": []}}`,
+	} {
+		if _, err := ParseIntrospection([]byte(body)); err == nil {
+			t.Errorf("ParseIntrospection(%s): expected error", body)
+		}
+	}
+}
+
+func TestValidator(t *testing.T) {
+	schema, err := ParseIntrospection([]byte(testIntrospection))
+	if err != nil {
+		t.Fatal(err)
+	}
+	v, err := NewValidator(schema.SDL())
+	if err != nil {
+		t.Fatalf("NewValidator: %v", err)
+	}
+
+	tests := []struct {
+		query, op string
+		errPart   string
+	}{
+		{`query GetUser($id: ID!) { user(id: $id) { id name role } }`, "", ""},
+		{`mutation { createUser(input: {name: "a"}) { id } }`, "", ""},
+		{`{ user(id: 1) { email } }`, "", "email"},
+		{`{ users(first: "x") { id } }`, "", "Int"},
+		{`query A { users { id } } query B { users { name } }`, "B", ""},
+		{`query A { users { id } } query B { users { name } }`, "C", "C"},
+		{`{ user(id: 1) { id }`, "", "Expected"},
+	}
+	for _, tt := range tests {
+		errs := v.Validate(tt.query, tt.op)
+		switch {
+		case tt.errPart == "" && len(errs) > 0:
+			t.Errorf("Validate(%s): unexpected errors %v", tt.query, errs)
+		case tt.errPart != "" && (len(errs) == 0 || !strings.Contains(errs[0].Message, tt.errPart)):
+			t.Errorf("Validate(%s, %q) = %v, want error containing %q", tt.query, tt.op, errs, tt.errPart)
+		}
+	}
+
+	ops, errs := ParseOperations(`query A { users { id } } mutation B { createUser(input: {name: "x"}) { id } }`)
+	if len(errs) != 0 || len(ops) != 2 || ops[0].Name != "A" || ops[1].Name != "B" {
+		t.Errorf("ParseOperations = %+v, %v", ops, errs)
+	}
+}
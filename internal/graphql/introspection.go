@@ -0,0 +1,258 @@
+// Package graphql 解析 GraphQL 内省结果、生成 SDL，并基于 schema 校验查询
+// 不负责发送请求，发送与缓存由 proxy 包完成
+package graphql
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IntrospectionQuery 标准内省查询
+// 不查询 isRepeatable 等较新的字段，兼容尚未支持 2021 版规范的服务端
+const IntrospectionQuery = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+    directives {
+      name
+      locations
+      args { ...InputValue }
+    }
+  }
+}
+
+fragment FullType on __Type {
+  kind
+  name
+  fields(includeDeprecated: true) {
+    name
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) {
+    name
+    isDeprecated
+    deprecationReason
+  }
+  possibleTypes { ...TypeRef }
+}
+
+fragment InputValue on __InputValue {
+  name
+  type { ...TypeRef }
+  defaultValue
+}
+
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType {
+    kind
+    name
+    ofType {
+      kind
+      name
+      ofType {
+        kind
+        name
+        ofType {
+          kind
+          name
+          ofType {
+            kind
+            name
+            ofType {
+              kind
+              name
+              ofType { kind name }
+            }
+          }
+        }
+      }
+    }
+  }
+}`
+
+// Schema 内省结果中的 __schema
+type Schema struct {
+	QueryType        *NamedRef   `json:"queryType"`
+	MutationType     *NamedRef   `json:"mutationType"`
+	SubscriptionType *NamedRef   `json:"subscriptionType"`
+	Types            []FullType  `json:"types"`
+	Directives       []Directive `json:"directives"`
+}
+
+// NamedRef 只包含名称的类型引用
+type NamedRef struct {
+	Name string `json:"name"`
+}
+
+// FullType 完整类型定义
+type FullType struct {
+	Kind          string       `json:"kind"`
+	Name          string       `json:"name"`
+	Fields        []Field      `json:"fields"`
+	InputFields   []InputValue `json:"inputFields"`
+	Interfaces    []TypeRef    `json:"interfaces"`
+	EnumValues    []EnumValue  `json:"enumValues"`
+	PossibleTypes []TypeRef    `json:"possibleTypes"`
+}
+
+// Field 对象或接口的字段
+type Field struct {
+	Name              string       `json:"name"`
+	Args              []InputValue `json:"args"`
+	Type              TypeRef      `json:"type"`
+	IsDeprecated      bool         `json:"isDeprecated"`
+	DeprecationReason *string      `json:"deprecationReason"`
+}
+
+// InputValue 参数或输入对象字段
+type InputValue struct {
+	Name         string  `json:"name"`
+	Type         TypeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue"`
+}
+
+// EnumValue 枚举值
+type EnumValue struct {
+	Name              string  `json:"name"`
+	IsDeprecated      bool    `json:"isDeprecated"`
+	DeprecationReason *string `json:"deprecationReason"`
+}
+
+// TypeRef 类型引用 (NON_NULL / LIST 通过 OfType 嵌套)
+type TypeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *TypeRef `json:"ofType"`
+}
+
+// Directive 指令定义
+type Directive struct {
+	Name         string       `json:"name"`
+	IsRepeatable bool         `json:"isRepeatable"` // 内省查询未请求，保留以兼容直接提供的内省结果
+	Locations    []string     `json:"locations"`
+	Args         []InputValue `json:"args"`
+}
+
+// String 返回 SDL 形式的类型，例如 [User!]!
+func (t TypeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		if t.OfType == nil {
+			return ""
+		}
+		return t.OfType.String() + "!"
+	case "LIST":
+		if t.OfType == nil {
+			return "[]"
+		}
+		return "[" + t.OfType.String() + "]"
+	default:
+		return t.Name
+	}
+}
+
+// ParseIntrospection 解析内省响应 ({"data": {"__schema": ...}} 或直接的 {"__schema": ...})
+func ParseIntrospection(body []byte) (*Schema, error) {
+	var resp struct {
+		Data *struct {
+			Schema *Schema `json:"__schema"`
+		} `json:"data"`
+		Schema *Schema `json:"__schema"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return nil, fmt.Errorf("内省响应不是有效的 JSON: %v", err)
+	}
+
+	schema := resp.Schema
+	if resp.Data != nil && resp.Data.Schema != nil {
+		schema = resp.Data.Schema
+	}
+	if schema == nil {
+		if len(resp.Errors) > 0 {
+			msgs := make([]string, len(resp.Errors))
+			for i, e := range resp.Errors {
+				msgs[i] = e.Message
+			}
+			return nil, fmt.Errorf("内省查询失败: %s", strings.Join(msgs, "; "))
+		}
+		return nil, fmt.Errorf("响应中没有 __schema (服务端可能关闭了内省)")
+	}
+	if schema.QueryType == nil {
+		return nil, fmt.Errorf("schema 缺少 Query 根类型")
+	}
+	return schema, nil
+}
+
+// Operation schema 中可调用的根字段
+type Operation struct {
+	Name       string     `json:"name"`
+	Args       []Argument `json:"args,omitempty"`
+	Type       string     `json:"type"` // 返回类型
+	Deprecated bool       `json:"deprecated,omitempty"`
+}
+
+// Argument 根字段参数
+type Argument struct {
+	Name         string  `json:"name"`
+	Type         string  `json:"type"`
+	DefaultValue *string `json:"default_value,omitempty"`
+}
+
+// Operations 按操作类型分组的根字段
+type Operations struct {
+	Query        []Operation `json:"query"`
+	Mutation     []Operation `json:"mutation"`
+	Subscription []Operation `json:"subscription"`
+}
+
+// Operations 列出 Query / Mutation / Subscription 根类型上的字段
+func (s *Schema) Operations() Operations {
+	return Operations{
+		Query:        s.rootFields(s.QueryType),
+		Mutation:     s.rootFields(s.MutationType),
+		Subscription: s.rootFields(s.SubscriptionType),
+	}
+}
+
+func (s *Schema) rootFields(root *NamedRef) []Operation {
+	list := []Operation{}
+	if root == nil {
+		return list
+	}
+	t := s.typeByName(root.Name)
+	if t == nil {
+		return list
+	}
+	for _, f := range t.Fields {
+		op := Operation{Name: f.Name, Type: f.Type.String(), Deprecated: f.IsDeprecated}
+		for _, a := range f.Args {
+			op.Args = append(op.Args, Argument{Name: a.Name, Type: a.Type.String(), DefaultValue: a.DefaultValue})
+		}
+		list = append(list, op)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+func (s *Schema) typeByName(name string) *FullType {
+	for i := range s.Types {
+		if s.Types[i].Name == name {
+			return &s.Types[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+package graphql
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// 校验器内置 (prelude) 的标量与指令，生成 SDL 时跳过以免重复定义
+var (
+	builtinScalars    = map[string]bool{"Int": true, "Float": true, "String": true, "Boolean": true, "ID": true}
+	builtinDirectives = map[string]bool{"defer": true, "include": true, "skip": true, "deprecated": true, "specifiedBy": true, "oneOf": true}
+)
+
+// SDL 将内省结果转换为 Schema Definition Language 文本
+// 内省类型 (__ 开头) 与内置标量、指令由校验器自带，不会输出
+func (s *Schema) SDL() string {
+	var b strings.Builder
+
+	b.WriteString("schema {\n")
+	b.WriteString("  query: " + s.QueryType.Name + "\n")
+	if s.MutationType != nil {
+		b.WriteString("  mutation: " + s.MutationType.Name + "\n")
+	}
+	if s.SubscriptionType != nil {
+		b.WriteString("  subscription: " + s.SubscriptionType.Name + "\n")
+	}
+	b.WriteString("}\n")
+
+	for _, d := range s.Directives {
+		if builtinDirectives[d.Name] {
+			continue
+		}
+		b.WriteString("\ndirective @" + d.Name + writeArgs(d.Args))
+		if d.IsRepeatable {
+			b.WriteString(" repeatable")
+		}
+		b.WriteString(" on " + strings.Join(d.Locations, " | ") + "\n")
+	}
+
+	for _, t := range s.Types {
+		if strings.HasPrefix(t.Name, "__") || builtinScalars[t.Name] {
+			continue
+		}
+		b.WriteString("\n")
+		switch t.Kind {
+		case "SCALAR":
+			b.WriteString("scalar " + t.Name + "\n")
+		case "OBJECT", "INTERFACE":
+			keyword := "type"
+			if t.Kind == "INTERFACE" {
+				keyword = "interface"
+			}
+			b.WriteString(keyword + " " + t.Name + writeImplements(t.Interfaces) + " {\n")
+			for _, f := range t.Fields {
+				b.WriteString("  " + f.Name + writeArgs(f.Args) + ": " + f.Type.String())
+				b.WriteString(writeDeprecated(f.IsDeprecated, f.DeprecationReason) + "\n")
+			}
+			b.WriteString("}\n")
+		case "UNION":
+			names := make([]string, len(t.PossibleTypes))
+			for i, p := range t.PossibleTypes {
+				names[i] = p.Name
+			}
+			b.WriteString("union " + t.Name + " = " + strings.Join(names, " | ") + "\n")
+		case "ENUM":
+			b.WriteString("enum " + t.Name + " {\n")
+			for _, v := range t.EnumValues {
+				b.WriteString("  " + v.Name + writeDeprecated(v.IsDeprecated, v.DeprecationReason) + "\n")
+			}
+			b.WriteString("}\n")
+		case "INPUT_OBJECT":
+			b.WriteString("input " + t.Name + " {\n")
+			for _, f := range t.InputFields {
+				b.WriteString("  " + writeInputValue(f) + "\n")
+			}
+			b.WriteString("}\n")
+		}
+	}
+	return b.String()
+}
+
+func writeImplements(list []TypeRef) string {
+	if len(list) == 0 {
+		return ""
+	}
+	names := make([]string, len(list))
+	for i, t := range list {
+		names[i] = t.Name
+	}
+	return " implements " + strings.Join(names, " & ")
+}
+
+func writeArgs(args []InputValue) string {
+	if len(args) == 0 {
+		return ""
+	}
+	parts := make([]string, len(args))
+	for i, a := range args {
+		parts[i] = writeInputValue(a)
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+func writeInputValue(v InputValue) string {
+	s := v.Name + ": " + v.Type.String()
+	if v.DefaultValue != nil {
+		s += " = " + *v.DefaultValue
+	}
+	return s
+}
+
+func writeDeprecated(deprecated bool, reason *string) string {
+	if !deprecated {
+		return ""
+	}
+	if reason == nil || *reason == "" {
+		return " @deprecated"
+	}
+	// JSON 字符串转义与 GraphQL 字符串兼容
+	quoted, _ := json.Marshal(*reason)
+	return " @deprecated(reason: " + string(quoted) + ")"
+}
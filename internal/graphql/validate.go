@@ -0,0 +1,109 @@
+package graphql
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/vektah/gqlparser/v2"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+	"github.com/vektah/gqlparser/v2/parser"
+	"github.com/vektah/gqlparser/v2/validator"
+	"github.com/vektah/gqlparser/v2/validator/rules"
+)
+
+// QueryError 查询校验错误
+type QueryError struct {
+	Message string `json:"message"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+}
+
+func (e QueryError) String() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%d:%d %s", e.Line, e.Column, e.Message)
+	}
+	return e.Message
+}
+
+// DocumentOperation 查询文档中定义的操作
+type DocumentOperation struct {
+	Name string `json:"name"` // 匿名操作为空
+	Type string `json:"type"` // query | mutation | subscription
+}
+
+// Validator 基于 SDL 构建的查询校验器，可并发复用
+type Validator struct {
+	schema *ast.Schema
+}
+
+// NewValidator 加载 SDL 构建校验器
+func NewValidator(sdl string) (*Validator, error) {
+	schema, err := gqlparser.LoadSchema(&ast.Source{Name: "schema.graphql", Input: sdl})
+	if err != nil {
+		return nil, fmt.Errorf("schema 无法加载: %v", err)
+	}
+	return &Validator{schema: schema}, nil
+}
+
+// Validate 校验查询是否符合 schema，并检查 operationName 能否确定要执行的操作
+func (v *Validator) Validate(query, operationName string) []QueryError {
+	doc, errs := parse(query)
+	if len(errs) > 0 {
+		return errs
+	}
+	if list := validator.ValidateWithRules(v.schema, doc, rules.NewDefaultRules()); len(list) > 0 {
+		return convertErrors(list)
+	}
+	return checkOperationName(doc, operationName)
+}
+
+// ParseOperations 解析查询文档中的操作 (只做语法检查，不需要 schema)
+func ParseOperations(query string) ([]DocumentOperation, []QueryError) {
+	doc, errs := parse(query)
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	ops := []DocumentOperation{}
+	for _, op := range doc.Operations {
+		ops = append(ops, DocumentOperation{Name: op.Name, Type: string(op.Operation)})
+	}
+	return ops, nil
+}
+
+func parse(query string) (*ast.QueryDocument, []QueryError) {
+	doc, err := parser.ParseQuery(&ast.Source{Input: query})
+	if err != nil {
+		var gqlErr *gqlerror.Error
+		if errors.As(err, &gqlErr) {
+			return nil, convertErrors(gqlerror.List{gqlErr})
+		}
+		return nil, []QueryError{{Message: err.Error()}}
+	}
+	return doc, nil
+}
+
+func checkOperationName(doc *ast.QueryDocument, name string) []QueryError {
+	if name == "" {
+		if len(doc.Operations) > 1 {
+			return []QueryError{{Message: "查询包含多个操作，需要指定 operationName"}}
+		}
+		return nil
+	}
+	if doc.Operations.ForName(name) == nil {
+		return []QueryError{{Message: fmt.Sprintf("查询中不存在操作 %q", name)}}
+	}
+	return nil
+}
+
+func convertErrors(list gqlerror.List) []QueryError {
+	out := make([]QueryError, 0, len(list))
+	for _, e := range list {
+		qe := QueryError{Message: e.Message}
+		if len(e.Locations) > 0 {
+			qe.Line, qe.Column = e.Locations[0].Line, e.Locations[0].Column
+		}
+		out = append(out, qe)
+	}
+	return out
+}
@@ -0,0 +1,132 @@
+// Package jsonpath 实现一个精简的 JSON Path 查询，用于断言、变量提取与 Mock 条件匹配
+//
+// 支持的语法:
+//
+//	$.data.items[0].id
+//	data.items[-1].name     (省略 $，负数下标从末尾计数)
+//	$['key.with.dots'][0]
+package jsonpath
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Lookup 解析 JSON 文本并按路径取值
+// 数字保留为 json.Number，超过 2^53 的整数与大数不会因转换为 float64 而失真
+func Lookup(body []byte, path string) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	var doc interface{}
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("响应不是有效的 JSON: %v", err)
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("响应不是有效的 JSON: 末尾存在多余内容")
+	}
+	return Get(doc, path)
+}
+
+// Get 在已解析的 JSON 文档中按路径取值
+func Get(doc interface{}, path string) (interface{}, error) {
+	tokens, err := parse(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cur := doc
+	for _, t := range tokens {
+		switch node := cur.(type) {
+		case map[string]interface{}:
+			if t.isIndex {
+				return nil, fmt.Errorf("路径 %s: 对象不支持下标 [%d]", path, t.index)
+			}
+			v, ok := node[t.key]
+			if !ok {
+				return nil, fmt.Errorf("路径 %s: 字段 %q 不存在", path, t.key)
+			}
+			cur = v
+		case []interface{}:
+			if !t.isIndex {
+				return nil, fmt.Errorf("路径 %s: 数组不支持字段 %q", path, t.key)
+			}
+			i := t.index
+			if i < 0 {
+				i += len(node)
+			}
+			if i < 0 || i >= len(node) {
+				return nil, fmt.Errorf("路径 %s: 下标 %d 越界", path, t.index)
+			}
+			cur = node[i]
+		default:
+			return nil, fmt.Errorf("路径 %s: 无法在标量值上继续取值", path)
+		}
+	}
+	return cur, nil
+}
+
+// Stringify 将取到的值转换为便于比较的字符串
+// 字符串原样返回，json.Number 返回原始文本，null 返回 "null"，其余值返回紧凑 JSON
+func Stringify(v interface{}) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	case nil:
+		return "null"
+	default:
+		data, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(data)
+	}
+}
+
+type token struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+func parse(path string) ([]token, error) {
+	p := strings.TrimSpace(path)
+	p = strings.TrimPrefix(p, "$")
+
+	var tokens []token
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("路径 %s: 缺少 ]", path)
+			}
+			inner := strings.TrimSpace(p[1:end])
+			p = p[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				tokens = append(tokens, token{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("路径 %s: 无效的下标 [%s]", path, inner)
+			}
+			tokens = append(tokens, token{index: idx, isIndex: true})
+		default:
+			end := strings.IndexAny(p, ".[")
+			if end < 0 {
+				end = len(p)
+			}
+			tokens = append(tokens, token{key: p[:end]})
+			p = p[end:]
+		}
+	}
+	return tokens, nil
+}
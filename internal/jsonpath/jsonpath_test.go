@@ -0,0 +1,61 @@
+package jsonpath
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	body := []byte(`{
+		"id": 9007199254740993,
+		"big": 1000000000000000000000,
+		"price": 19.90,
+		"neg": -12,
+		"data": {"items": [{"id": 1, "name": "a"}, {"id": 2, "name": "b", "tags": ["x", "y"]}]},
+		"key.with.dots": {"v": true},
+		"empty": null
+	}`)
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"$.id", "9007199254740993"},
+		{"big", "1000000000000000000000"},
+		{"$.price", "19.90"},
+		{"neg", "-12"},
+		{"$.data.items[0].name", "a"},
+		{"data.items[-1].id", "2"},
+		{"$.data.items[1].tags", `["x","y"]`},
+		{"$.data.items[0]", `{"id":1,"name":"a"}`},
+		{"$['key.with.dots'].v", "true"},
+		{"$.empty", "null"},
+	}
+	for _, tt := range tests {
+		v, err := Lookup(body, tt.path)
+		if err != nil {
+			t.Errorf("Lookup(%s): %v", tt.path, err)
+			continue
+		}
+		if got := Stringify(v); got != tt.want {
+			t.Errorf("Lookup(%s) = %s, want %s", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLookupErrors(t *testing.T) {
+	tests := []struct {
+		body, path string
+	}{
+		{`{"a": 1}`, "$.b"},
+		{`{"a": [1]}`, "$.a[3]"},
+		{`{"a": [1]}`, "$.a.b"},
+		{`{"a": 1}`, "$.a.b"},
+		{`{"a": 1}`, "$[0]"},
+		{`{"a": 1}`, "$.a[x]"},
+		{`{"a": 1}`, "$['a'"},
+		{`not json`, "$.a"},
+		{`{"a": 1} {"a": 2}`, "$.a"},
+	}
+	for _, tt := range tests {
+		if v, err := Lookup([]byte(tt.body), tt.path); err == nil {
+			t.Errorf("Lookup(%s, %s) = %v, want error", tt.body, tt.path, v)
+		}
+	}
+}
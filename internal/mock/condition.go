@@ -0,0 +1,165 @@
+package mock
+
+import (
+	"fmt"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/jsonpath"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// response 选中的响应 (状态码、响应头与响应体均为未渲染的模板)
+type response struct {
+	variant string // 命中的条件响应名称，默认响应为空
+	status  int
+	headers map[string]string
+	body    string
+}
+
+// selectResponse 按顺序评估条件响应，均未命中时返回规则的默认响应
+func selectResponse(rule *database.MockRule, r *http.Request, body []byte, doc interface{}) response {
+	for i, v := range rule.Responses {
+		if !matchConditions(v.Conditions, r, body, doc) {
+			continue
+		}
+		resp := response{variant: v.Name, status: v.StatusCode, body: v.Body}
+		if resp.variant == "" {
+			resp.variant = fmt.Sprintf("#%d", i+1)
+		}
+		if resp.status == 0 {
+			resp.status = rule.StatusCode
+		}
+		resp.headers = make(map[string]string, len(rule.ResponseHeaders)+len(v.Headers))
+		for k, val := range rule.ResponseHeaders {
+			resp.headers[k] = val
+		}
+		for k, val := range v.Headers {
+			resp.headers[k] = val
+		}
+		return resp
+	}
+	return response{status: rule.StatusCode, headers: rule.ResponseHeaders, body: rule.ResponseBody}
+}
+
+func matchConditions(conds []database.MockCondition, r *http.Request, body []byte, doc interface{}) bool {
+	for _, c := range conds {
+		if !matchCondition(c, r, body, doc) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchCondition 取出条件对应的实际值并按 Operator 比较
+func matchCondition(c database.MockCondition, r *http.Request, body []byte, doc interface{}) bool {
+	var (
+		actual string
+		exists bool
+	)
+	switch c.Source {
+	case database.MockMatchQuery:
+		if values, ok := r.URL.Query()[c.Key]; ok && len(values) > 0 {
+			actual, exists = values[0], true
+		}
+	case database.MockMatchHeader:
+		if values := r.Header.Values(c.Key); len(values) > 0 {
+			actual, exists = values[0], true
+		}
+	case database.MockMatchJSONPath:
+		if doc != nil {
+			if v, err := jsonpath.Get(doc, c.Key); err == nil {
+				actual, exists = jsonpath.Stringify(v), true
+			}
+		}
+	case database.MockMatchBodyRegex:
+		re, err := compileRegex(c.Value)
+		return err == nil && re.Match(body)
+	default:
+		return false
+	}
+
+	switch c.Operator {
+	case database.MockOpExists:
+		return exists
+	case database.MockOpNotExists:
+		return !exists
+	case database.MockOpNotEquals:
+		return !exists || actual != c.Value
+	case database.MockOpContains:
+		return exists && strings.Contains(actual, c.Value)
+	case database.MockOpMatches:
+		re, err := compileRegex(c.Value)
+		return exists && err == nil && re.MatchString(actual)
+	default:
+		return exists && actual == c.Value
+	}
+}
+
+// regexCache 按表达式缓存条件中的正则
+var regexCache sync.Map
+
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(expr, re)
+	return re, nil
+}
+
+// ValidateRule 检查规则的路径模式、延迟与故障、场景配置、响应模板以及条件响应是否合法
+func ValidateRule(rule *database.MockRule) error {
+	if err := ValidatePattern(rule.PathPattern); err != nil {
+		return err
+	}
+	if err := validateFault(rule); err != nil {
+		return err
+	}
+	if err := validateScenario(rule); err != nil {
+		return err
+	}
+	if err := validateTemplates(rule); err != nil {
+		return err
+	}
+	for i, v := range rule.Responses {
+		for _, c := range v.Conditions {
+			if err := validateCondition(c); err != nil {
+				return fmt.Errorf("response #%d: %v", i+1, err)
+			}
+		}
+	}
+	return nil
+}
+
+func validateCondition(c database.MockCondition) error {
+	switch c.Source {
+	case database.MockMatchQuery, database.MockMatchHeader, database.MockMatchJSONPath:
+		if c.Key == "" {
+			return fmt.Errorf("condition on %s requires a key", c.Source)
+		}
+	case database.MockMatchBodyRegex:
+		if _, err := regexp.Compile(c.Value); err != nil {
+			return fmt.Errorf("invalid body regex: %v", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown condition source: %q", c.Source)
+	}
+
+	switch c.Operator {
+	case "", database.MockOpEquals, database.MockOpNotEquals, database.MockOpContains,
+		database.MockOpExists, database.MockOpNotExists:
+	case database.MockOpMatches:
+		if _, err := regexp.Compile(c.Value); err != nil {
+			return fmt.Errorf("invalid regex: %v", err)
+		}
+	default:
+		return fmt.Errorf("unknown condition operator: %q", c.Operator)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+package mock
+
+import (
+	"go-api-tester/internal/database"
+	"strings"
+	"testing"
+)
+
+func TestConditionalResponses(t *testing.T) {
+	login := &database.MockRule{
+		PathPattern: "/login", Method: "POST", StatusCode: 200, ResponseBody: "default",
+		ResponseHeaders: map[string]string{"X-Rule": "login", "X-Variant": "none"},
+		Responses: []database.MockResponse{
+			{Name: "locked", StatusCode: 423, Body: "locked",
+				Conditions: []database.MockCondition{{Source: database.MockMatchJSONPath, Key: "$.user", Value: "blocked"}}},
+			{Name: "bad password", StatusCode: 401, Body: "wrong password", Headers: map[string]string{"X-Variant": "bad"},
+				Conditions: []database.MockCondition{
+					{Source: database.MockMatchJSONPath, Key: "$.password", Operator: database.MockOpNotEquals, Value: "secret"},
+					{Source: database.MockMatchHeader, Key: "X-Client", Operator: database.MockOpExists},
+				}},
+			{Name: "debug", Body: "debug",
+				Conditions: []database.MockCondition{{Source: database.MockMatchQuery, Key: "mode", Operator: database.MockOpMatches, Value: "^dbg|debug$"}}},
+			{Name: "xml", StatusCode: 415, Body: "no xml",
+				Conditions: []database.MockCondition{{Source: database.MockMatchBodyRegex, Value: `^\s*<`}}},
+			{Name: "beta", StatusCode: 202, Body: "beta",
+				Conditions: []database.MockCondition{
+					{Source: database.MockMatchHeader, Key: "User-Agent", Operator: database.MockOpContains, Value: "beta"},
+					{Source: database.MockMatchQuery, Key: "legacy", Operator: database.MockOpNotExists},
+				}},
+		},
+	}
+	srv := setupMock(t, login)
+
+	tests := []struct {
+		name    string
+		path    string
+		body    string
+		headers []string
+		status  int
+		resp    string
+		variant string
+	}{
+		{"first matching variant wins", "/login?mode=debug", `{"user":"blocked","password":"x"}`, []string{"X-Client", "1"}, 423, "locked", "none"},
+		{"all conditions must match", "/login", `{"user":"ann","password":"x"}`, []string{"X-Client", "1"}, 401, "wrong password", "bad"},
+		{"missing header skips variant", "/login", `{"user":"ann","password":"x"}`, nil, 200, "default", "none"},
+		{"variant inherits rule status", "/login?mode=dbg", `{"user":"ann","password":"secret"}`, nil, 200, "debug", "none"},
+		{"body regex", "/login", `<login/>`, nil, 415, "no xml", "none"},
+		{"contains and not_exists", "/login", ``, []string{"User-Agent", "app-beta/1.0"}, 202, "beta", "none"},
+		{"not_exists fails when present", "/login?legacy=1", ``, []string{"User-Agent", "app-beta/1.0"}, 200, "default", "none"},
+		{"default response", "/login", `{"user":"ann","password":"secret"}`, nil, 200, "default", "none"},
+	}
+	for _, tt := range tests {
+		status, h, body := call(t, srv, "POST", tt.path, tt.body, tt.headers...)
+		if status != tt.status || body != tt.resp {
+			t.Errorf("%s: got %d %q, want %d %q", tt.name, status, body, tt.status, tt.resp)
+		}
+		if h.Get("X-Rule") != "login" || h.Get("X-Variant") != tt.variant {
+			t.Errorf("%s: headers X-Rule=%q X-Variant=%q, want login/%s", tt.name, h.Get("X-Rule"), h.Get("X-Variant"), tt.variant)
+		}
+	}
+
+	if status, _, body := call(t, srv, "GET", "/login", ""); status != 404 || !strings.Contains(body, "No mock rule") {
+		t.Errorf("method mismatch: got %d %q", status, body)
+	}
+}
+
+func TestValidateConditions(t *testing.T) {
+	tests := []struct {
+		name string
+		cond database.MockCondition
+		ok   bool
+	}{
+		{"query equals", database.MockCondition{Source: database.MockMatchQuery, Key: "a", Value: "1"}, true},
+		{"missing key", database.MockCondition{Source: database.MockMatchHeader, Operator: database.MockOpExists}, false},
+		{"unknown source", database.MockCondition{Source: "cookie", Key: "a"}, false},
+		{"unknown operator", database.MockCondition{Source: database.MockMatchQuery, Key: "a", Operator: "gt"}, false},
+		{"bad regex", database.MockCondition{Source: database.MockMatchQuery, Key: "a", Operator: database.MockOpMatches, Value: "("}, false},
+		{"bad body regex", database.MockCondition{Source: database.MockMatchBodyRegex, Value: "["}, false},
+	}
+	for _, tt := range tests {
+		rule := &database.MockRule{PathPattern: "/a", Responses: []database.MockResponse{{Conditions: []database.MockCondition{tt.cond}}}}
+		if err := ValidateRule(rule); (err == nil) != tt.ok {
+			t.Errorf("%s: ValidateRule() = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -2,14 +2,17 @@ package mock
 
 import (
 	"database/sql"
-	"encoding/json"
 	"fmt"
 	"go-api-tester/internal/database"
+	"io"
 	"log"
 	"net/http"
 	"strings"
 )
 
+// maxRequestBody 渲染模板时读取的请求体上限
+const maxRequestBody = 10 << 20
+
 // HandleMockRequest 处理所有以 /mock/ 开头的请求
 func HandleMockRequest(w http.ResponseWriter, r *http.Request) {
 	// 1. 获取真实路径
@@ -29,7 +32,8 @@ func HandleMockRequest(w http.ResponseWriter, r *http.Request) {
 	method := r.Method
 
 	// 2. 查找匹配的规则
-	rule, err := findMatchingRule(path, method)
+	// 规则设置了 NewState 时场景状态在此切换 (之后的延迟、模板错误与故障注入均视为已处理本次调用)
+	rule, params, state, err := findMatchingRule(path, method)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			w.WriteHeader(http.StatusNotFound)
@@ -40,47 +44,114 @@ func HandleMockRequest(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 3. 模拟延迟 (可选，未来可配置)
-	// time.Sleep(time.Duration(rule.DelayMs) * time.Millisecond)
+	// 3. 模拟延迟 (固定延迟 + 随机抖动)，等待期间客户端断开则不再响应
+	if !wait(r, rule) {
+		return
+	}
+
+	// 4. 读取请求体，按顺序评估条件响应 (均未命中时使用默认响应)
+	reqBody, err := io.ReadAll(io.LimitReader(r.Body, maxRequestBody))
+	if err != nil {
+		http.Error(w, "Read body failed: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	doc := parseJSONBody(reqBody)
+	resp := selectResponse(rule, r, reqBody, doc)
+
+	// 5. 规则启用模板时渲染响应头与响应体 (可引用路径参数、查询参数、请求头与请求体)，否则原样返回
+	headers, body := resp.headers, resp.body
+	if rule.Template {
+		data := templateData(r, path, reqBody, doc, params)
+		data["scenario"], data["state"] = rule.Scenario, state
+		headers = make(map[string]string, len(resp.headers))
+		for k, v := range resp.headers {
+			if headers[k], err = renderTemplate("header "+k, v, data); err != nil {
+				http.Error(w, "Mock template error: "+err.Error(), http.StatusInternalServerError)
+				return
+			}
+		}
+		if body, err = renderTemplate("body", resp.body, data); err != nil {
+			http.Error(w, "Mock template error: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
 
-	// 4. 设置响应头
-	for k, v := range rule.ResponseHeaders {
+	for k, v := range headers {
 		w.Header().Set(k, v)
 	}
 
-	// 5. 设置状态码
-	w.WriteHeader(rule.StatusCode)
+	// 6. 故障注入 (按概率触发)
+	if f := triggeredFault(rule.Fault); f != nil {
+		log.Printf("[MOCK] Matched: [%s] %s -> Fault %s", method, path, f.Type)
+		injectFault(w, r, f, resp.status, body)
+		return
+	}
+
+	// 7. 设置状态码
+	w.WriteHeader(resp.status)
+
+	// 8. 写入响应体
+	w.Write([]byte(body))
 
-	// 6. 写入响应体
-	w.Write([]byte(rule.ResponseBody))
-	
-	log.Printf("[MOCK] Matched: [%s] %s -> Status %d", method, path, rule.StatusCode)
+	if resp.variant != "" {
+		log.Printf("[MOCK] Matched: [%s] %s -> Response %s, Status %d", method, path, resp.variant, resp.status)
+	} else {
+		log.Printf("[MOCK] Matched: [%s] %s -> Status %d", method, path, resp.status)
+	}
 }
 
-// findMatchingRule 在数据库中查找规则
-// 目前支持精确匹配。未来可扩展支持通配符 (如 /users/*) 或正则
-func findMatchingRule(path, method string) (*database.MockRule, error) {
-	// 简单精确匹配
-	query := `
-		SELECT id, path_pattern, method, response_body, response_headers, status_code, is_active 
-		FROM mock_rules 
-		WHERE path_pattern = ? AND method = ? AND is_active = 1
-		LIMIT 1
-	`
-	
-	row := database.DB.QueryRow(query, path, method)
-
-	var r database.MockRule
-	var headersStr string
-	
-	err := row.Scan(&r.ID, &r.PathPattern, &r.Method, &r.ResponseBody, &headersStr, &r.StatusCode, &r.IsActive)
+// findMatchingRule 查找与路径匹配的启用规则，返回规则、捕获的路径参数以及场景切换前的状态
+// 要求场景状态 (RequiredState) 的规则只在场景处于该状态时参与匹配
+// 多条规则匹配时按模式优先级 (精确 > 参数 > 通配 > 正则) 选择，优先级相同时要求场景状态的规则优先，其次取 ID 最小的规则
+// 选中的规则设置了 NewState 时立即切换场景状态；切换前状态已被并发请求改变则按新状态重新匹配
+func findMatchingRule(path, method string) (*database.MockRule, map[string]string, string, error) {
+	rules, err := database.GetActiveMockRules(method)
 	if err != nil {
-		return nil, err
+		return nil, nil, "", err
 	}
 
-	if headersStr != "" {
-		_ = json.Unmarshal([]byte(headersStr), &r.ResponseHeaders)
+	for {
+		rule, params := matchRule(rules, path)
+		if rule == nil {
+			return nil, nil, "", sql.ErrNoRows
+		}
+		if rule.Scenario == "" {
+			return rule, params, "", nil
+		}
+		if rule.NewState == "" {
+			return rule, params, scenarios.state(rule.Scenario), nil
+		}
+		if prev, ok := scenarios.claim(rule.Scenario, rule.RequiredState, rule.NewState); ok {
+			return rule, params, prev, nil
+		}
 	}
+}
 
-	return &r, nil
-}
\ No newline at end of file
+// matchRule 按场景当前状态与模式优先级选择规则
+func matchRule(rules []*database.MockRule, path string) (*database.MockRule, map[string]string) {
+	var (
+		best       *database.MockRule
+		bestPat    *pattern
+		bestParams map[string]string
+	)
+	for _, rule := range rules {
+		if rule.RequiredState != "" && scenarios.state(rule.Scenario) != rule.RequiredState {
+			continue
+		}
+		p, err := rulePattern(rule)
+		if err != nil {
+			log.Printf("[MOCK] Skip rule %d: %v", rule.ID, err)
+			continue
+		}
+		params, ok := p.match(path)
+		if !ok {
+			continue
+		}
+		// 规则按 ID 升序，只有更优先时才替换
+		if best == nil || p.morePrecise(bestPat) ||
+			(!bestPat.morePrecise(p) && rule.RequiredState != "" && best.RequiredState == "") {
+			best, bestPat, bestParams = rule, p, params
+		}
+	}
+	return best, bestParams
+}
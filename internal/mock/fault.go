@@ -0,0 +1,136 @@
+package mock
+
+import (
+	"fmt"
+	"go-api-tester/internal/database"
+	mrand "math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// 单个规则允许的最大延迟，避免误配置导致请求长时间挂起
+const maxDelay = 5 * time.Minute
+
+// wait 按规则等待固定延迟加随机抖动，客户端断开时提前返回 false
+func wait(r *http.Request, rule *database.MockRule) bool {
+	d := time.Duration(rule.DelayMs) * time.Millisecond
+	if rule.JitterMs > 0 {
+		d += time.Duration(mrand.IntN(rule.JitterMs+1)) * time.Millisecond
+	}
+	if d <= 0 {
+		return true
+	}
+	return sleep(r, min(d, maxDelay))
+}
+
+func sleep(r *http.Request, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-r.Context().Done():
+		return false
+	}
+}
+
+// triggeredFault 按概率决定本次请求是否注入故障
+func triggeredFault(f *database.MockFault) *database.MockFault {
+	if f == nil || f.Type == "" {
+		return nil
+	}
+	if f.Probability > 0 && mrand.Float64() >= f.Probability {
+		return nil
+	}
+	return f
+}
+
+// injectFault 以故障方式写出响应，响应头已设置在 w 上
+func injectFault(w http.ResponseWriter, r *http.Request, f *database.MockFault, status int, body string) {
+	switch f.Type {
+	case database.MockFaultError:
+		code := f.StatusCode
+		if code == 0 {
+			code = http.StatusInternalServerError
+		}
+		w.WriteHeader(code)
+		w.Write([]byte(f.Body))
+
+	case database.MockFaultReset:
+		resetConnection(w)
+
+	case database.MockFaultTruncate:
+		n := f.TruncateBytes
+		if n <= 0 || n >= len(body) {
+			n = len(body) / 2
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(status)
+		w.Write([]byte(body[:n]))
+		if fl, ok := w.(http.Flusher); ok {
+			fl.Flush()
+		}
+		// 中止处理，服务端关闭连接，客户端读到的响应体不完整
+		panic(http.ErrAbortHandler)
+
+	case database.MockFaultTrickle:
+		chunk := max(f.ChunkBytes, 1)
+		delay := time.Duration(f.ChunkDelayMs) * time.Millisecond
+		if f.ChunkDelayMs <= 0 {
+			delay = 100 * time.Millisecond
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(status)
+		fl, _ := w.(http.Flusher)
+		for i := 0; i < len(body); i += chunk {
+			if i > 0 && !sleep(r, delay) {
+				return
+			}
+			w.Write([]byte(body[i:min(i+chunk, len(body))]))
+			if fl != nil {
+				fl.Flush()
+			}
+		}
+
+	case database.MockFaultTimeout:
+		<-r.Context().Done()
+	}
+}
+
+// resetConnection 接管连接并以 RST 关闭 (SO_LINGER 为 0)，不支持接管时 (HTTP/2) 中止请求
+func resetConnection(w http.ResponseWriter) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		panic(http.ErrAbortHandler)
+	}
+	conn, _, err := hj.Hijack()
+	if err != nil {
+		panic(http.ErrAbortHandler)
+	}
+	if tcp, ok := conn.(*net.TCPConn); ok {
+		tcp.SetLinger(0)
+	}
+	conn.Close()
+}
+
+func validateFault(rule *database.MockRule) error {
+	if rule.DelayMs < 0 || rule.JitterMs < 0 {
+		return fmt.Errorf("delay_ms and jitter_ms must not be negative")
+	}
+	f := rule.Fault
+	if f == nil || f.Type == "" {
+		return nil
+	}
+	switch f.Type {
+	case database.MockFaultError, database.MockFaultReset, database.MockFaultTruncate,
+		database.MockFaultTrickle, database.MockFaultTimeout:
+	default:
+		return fmt.Errorf("unknown fault type: %q", f.Type)
+	}
+	if f.Probability < 0 || f.Probability > 1 {
+		return fmt.Errorf("fault probability must be between 0 and 1")
+	}
+	return nil
+}
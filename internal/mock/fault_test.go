@@ -0,0 +1,115 @@
+package mock
+
+import (
+	"context"
+	"go-api-tester/internal/database"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestDelay(t *testing.T) {
+	srv := setupMock(t, &database.MockRule{PathPattern: "/slow", ResponseBody: "ok", DelayMs: 150, JitterMs: 50})
+	start := time.Now()
+	status, _, body := call(t, srv, "GET", "/slow", "")
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond {
+		t.Errorf("response after %v, want at least 150ms", elapsed)
+	}
+	if status != 200 || body != "ok" {
+		t.Errorf("got %d %q", status, body)
+	}
+}
+
+func TestFaultInjection(t *testing.T) {
+	body := "0123456789abcdefghij"
+	srv := setupMock(t,
+		&database.MockRule{PathPattern: "/error", ResponseBody: body,
+			Fault: &database.MockFault{Type: database.MockFaultError, StatusCode: 503, Body: "unavailable"}},
+		&database.MockRule{PathPattern: "/error-default", ResponseBody: body,
+			Fault: &database.MockFault{Type: database.MockFaultError, Probability: 1}},
+		&database.MockRule{PathPattern: "/reset", ResponseBody: body,
+			Fault: &database.MockFault{Type: database.MockFaultReset}},
+		&database.MockRule{PathPattern: "/truncate", ResponseBody: body,
+			Fault: &database.MockFault{Type: database.MockFaultTruncate, TruncateBytes: 5}},
+		&database.MockRule{PathPattern: "/trickle", ResponseBody: body,
+			Fault: &database.MockFault{Type: database.MockFaultTrickle, ChunkBytes: 5, ChunkDelayMs: 40}},
+		&database.MockRule{PathPattern: "/timeout", ResponseBody: body,
+			Fault: &database.MockFault{Type: database.MockFaultTimeout}},
+	)
+	get := func(path string, timeout time.Duration) (*http.Response, []byte, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/mock"+path, nil)
+		resp, err := srv.Client().Do(req)
+		if err != nil {
+			return nil, nil, err
+		}
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		return resp, b, err
+	}
+
+	if status, _, b := call(t, srv, "GET", "/error", ""); status != 503 || b != "unavailable" {
+		t.Errorf("error fault: got %d %q", status, b)
+	}
+	if status, _, _ := call(t, srv, "GET", "/error-default", ""); status != 500 {
+		t.Errorf("error fault default status: got %d", status)
+	}
+
+	if _, _, err := get("/reset", 5*time.Second); err == nil {
+		t.Error("reset fault: expected connection error")
+	}
+
+	resp, b, err := get("/truncate", 5*time.Second)
+	if err == nil || resp.ContentLength != int64(len(body)) || string(b) != body[:5] {
+		t.Errorf("truncate fault: read %q (Content-Length %d), err %v", b, resp.ContentLength, err)
+	}
+
+	start := time.Now()
+	_, b, err = get("/trickle", 5*time.Second)
+	if err != nil || string(b) != body {
+		t.Errorf("trickle fault: read %q, err %v", b, err)
+	}
+	if elapsed := time.Since(start); elapsed < 3*40*time.Millisecond {
+		t.Errorf("trickle fault finished after %v, want at least 120ms", elapsed)
+	}
+
+	if _, _, err := get("/timeout", 200*time.Millisecond); err == nil {
+		t.Error("timeout fault: expected client timeout")
+	}
+}
+
+func TestTriggeredFault(t *testing.T) {
+	if triggeredFault(nil) != nil || triggeredFault(&database.MockFault{}) != nil {
+		t.Error("empty fault should not trigger")
+	}
+	always := &database.MockFault{Type: database.MockFaultError}
+	rare := &database.MockFault{Type: database.MockFaultError, Probability: 1e-9}
+	for i := 0; i < 100; i++ {
+		if triggeredFault(always) == nil {
+			t.Fatal("probability 0 should always trigger")
+		}
+		if triggeredFault(rare) != nil {
+			t.Fatal("probability 1e-9 triggered")
+		}
+	}
+}
+
+func TestValidateFault(t *testing.T) {
+	tests := []struct {
+		rule database.MockRule
+		ok   bool
+	}{
+		{database.MockRule{PathPattern: "/a", DelayMs: 10, JitterMs: 5}, true},
+		{database.MockRule{PathPattern: "/a", DelayMs: -1}, false},
+		{database.MockRule{PathPattern: "/a", Fault: &database.MockFault{Type: database.MockFaultTrickle, Probability: 0.5}}, true},
+		{database.MockRule{PathPattern: "/a", Fault: &database.MockFault{Type: "explode"}}, false},
+		{database.MockRule{PathPattern: "/a", Fault: &database.MockFault{Type: database.MockFaultReset, Probability: 1.5}}, false},
+	}
+	for i, tt := range tests {
+		if err := ValidateRule(&tt.rule); (err == nil) != tt.ok {
+			t.Errorf("#%d: ValidateRule() = %v, want ok=%v", i, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package mock
+
+import (
+	"go-api-tester/internal/database"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupMock 打开临时数据库、重置场景并启动挂载 HandleMockRequest 的测试服务器
+func setupMock(t *testing.T, rules ...*database.MockRule) *httptest.Server {
+	t.Helper()
+	if err := database.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	ResetScenarios()
+	srv := httptest.NewServer(http.HandlerFunc(HandleMockRequest))
+	t.Cleanup(func() {
+		srv.Close()
+		database.DB.Close()
+		database.DB = nil
+	})
+	for _, rule := range rules {
+		addRule(t, rule)
+	}
+	return srv
+}
+
+// addRule 校验并保存规则，未设置的字段使用与接口相同的默认值
+func addRule(t *testing.T, rule *database.MockRule) {
+	t.Helper()
+	if rule.Method == "" {
+		rule.Method = "GET"
+	}
+	if rule.StatusCode == 0 {
+		rule.StatusCode = 200
+	}
+	rule.IsActive = true
+	if err := ValidateRule(rule); err != nil {
+		t.Fatalf("ValidateRule(%s): %v", rule.PathPattern, err)
+	}
+	id, err := database.CreateMockRule(rule)
+	if err != nil {
+		t.Fatalf("create rule: %v", err)
+	}
+	rule.ID = id
+}
+
+// call 向 /mock 发送请求，返回状态码、响应头与响应体
+func call(t *testing.T, srv *httptest.Server, method, path, body string, headers ...string) (int, http.Header, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+"/mock"+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i+1 < len(headers); i += 2 {
+		req.Header.Set(headers[i], headers[i+1])
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("read %s %s: %v", method, path, err)
+	}
+	return resp.StatusCode, resp.Header, string(b)
+}
@@ -0,0 +1,240 @@
+package mock
+
+import (
+	"fmt"
+	"go-api-tester/internal/database"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// 模式类型，同时也是匹配优先级 (数值越小越优先)
+const (
+	kindExact = iota
+	kindParam
+	kindWildcard
+	kindRegex
+)
+
+// 路径段类型，同一位置上数值越小越具体
+const (
+	segLiteral  = iota
+	segParam    // :id 或 {id}
+	segGlob     // 段内通配，例如 *.json
+	segStar     // * 匹配一段
+	segGlobstar // ** 匹配零或多段
+)
+
+type segment struct {
+	kind  int
+	value string // 字面值、参数名或段内通配模式
+}
+
+// pattern 编译后的路径模式
+type pattern struct {
+	kind      int
+	raw       string
+	segments  []segment
+	re        *regexp.Regexp
+	literals  int // 字面段数量，越多越具体
+	wildcards int // * 与 ** 的数量，用于为捕获结果编号
+}
+
+var paramName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// patternCache 按规则 ID 缓存编译结果 (ID -> *pattern)，规则更新或删除时由 ForgetRule 移除
+var patternCache sync.Map
+
+// ForgetRule 移除规则缓存的路径模式
+func ForgetRule(id int64) {
+	patternCache.Delete(id)
+}
+
+// ValidatePattern 检查路径模式是否合法 (正则能否编译、参数名是否有效)
+func ValidatePattern(raw string) error {
+	_, err := compilePattern(raw)
+	return err
+}
+
+// rulePattern 获取规则编译后的路径模式
+func rulePattern(rule *database.MockRule) (*pattern, error) {
+	if p, ok := patternCache.Load(rule.ID); ok && p.(*pattern).raw == rule.PathPattern {
+		return p.(*pattern), nil
+	}
+	p, err := compilePattern(rule.PathPattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(rule.ID, p)
+	return p, nil
+}
+
+func compilePattern(raw string) (*pattern, error) {
+	p := &pattern{raw: raw}
+	if strings.HasPrefix(raw, "~") {
+		re, err := regexp.Compile(`^(?:` + raw[1:] + `)$`)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex pattern: %v", err)
+		}
+		p.kind, p.re = kindRegex, re
+	} else {
+		p.kind = kindExact
+		for _, s := range splitPath(raw) {
+			seg, err := parseSegment(s)
+			if err != nil {
+				return nil, err
+			}
+			switch seg.kind {
+			case segLiteral:
+				p.literals++
+			case segParam:
+				p.kind = max(p.kind, kindParam)
+			case segStar, segGlobstar:
+				p.wildcards++
+				p.kind = kindWildcard
+			default:
+				p.kind = kindWildcard
+			}
+			p.segments = append(p.segments, seg)
+		}
+	}
+
+	return p, nil
+}
+
+func parseSegment(s string) (segment, error) {
+	var name string
+	switch {
+	case s == "*":
+		return segment{kind: segStar}, nil
+	case s == "**":
+		return segment{kind: segGlobstar}, nil
+	case strings.HasPrefix(s, ":"):
+		name = s[1:]
+	case strings.HasPrefix(s, "{") && strings.HasSuffix(s, "}"):
+		name = s[1 : len(s)-1]
+	case strings.Contains(s, "*"):
+		if _, err := path.Match(s, ""); err != nil {
+			return segment{}, fmt.Errorf("invalid wildcard segment: %s", s)
+		}
+		return segment{kind: segGlob, value: s}, nil
+	default:
+		return segment{kind: segLiteral, value: s}, nil
+	}
+	if !paramName.MatchString(name) {
+		return segment{}, fmt.Errorf("invalid path parameter name: %q", name)
+	}
+	return segment{kind: segParam, value: name}, nil
+}
+
+func splitPath(p string) []string {
+	return strings.Split(strings.TrimPrefix(p, "/"), "/")
+}
+
+// match 匹配路径并返回捕获的参数
+// 命名参数以名称为键；* 与 ** 按出现顺序、正则的未命名分组按分组序号以 "1"、"2" 为键
+func (p *pattern) match(urlPath string) (map[string]string, bool) {
+	params := make(map[string]string)
+	switch p.kind {
+	case kindExact:
+		return params, urlPath == p.raw
+	case kindRegex:
+		m := p.re.FindStringSubmatch(urlPath)
+		if m == nil {
+			return nil, false
+		}
+		for i, name := range p.re.SubexpNames() {
+			if i == 0 {
+				continue
+			}
+			if name == "" {
+				name = strconv.Itoa(i)
+			}
+			params[name] = m[i]
+		}
+		return params, true
+	}
+
+	var captures []string
+	if !matchSegments(p.segments, splitPath(urlPath), params, &captures) {
+		return nil, false
+	}
+	for i, c := range captures {
+		params[strconv.Itoa(i+1)] = c
+	}
+	return params, true
+}
+
+// matchSegments 逐段匹配，** 回溯尝试匹配不同数量的段
+func matchSegments(segs []segment, parts []string, params map[string]string, captures *[]string) bool {
+	if len(segs) == 0 {
+		return len(parts) == 0
+	}
+	seg := segs[0]
+	if seg.kind == segGlobstar {
+		n := len(*captures)
+		for i := 0; i <= len(parts); i++ {
+			*captures = append((*captures)[:n], strings.Join(parts[:i], "/"))
+			if matchSegments(segs[1:], parts[i:], params, captures) {
+				return true
+			}
+		}
+		*captures = (*captures)[:n]
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+
+	part := parts[0]
+	switch seg.kind {
+	case segLiteral:
+		if part != seg.value {
+			return false
+		}
+	case segParam:
+		if part == "" {
+			return false
+		}
+		params[seg.value] = part
+	case segGlob:
+		if ok, _ := path.Match(seg.value, part); !ok {
+			return false
+		}
+	case segStar:
+		if part == "" {
+			return false
+		}
+		n := len(*captures)
+		*captures = append(*captures, part)
+		if !matchSegments(segs[1:], parts[1:], params, captures) {
+			*captures = (*captures)[:n]
+			return false
+		}
+		return true
+	}
+	return matchSegments(segs[1:], parts[1:], params, captures)
+}
+
+// morePrecise 判断 p 是否比 q 优先
+// 依次比较: 模式类型 (精确 > 参数 > 通配 > 正则)、字面段数量、从左到右第一个不同段的具体程度
+// 完全相同时返回 false，由调用方按规则 ID 决定
+func (p *pattern) morePrecise(q *pattern) bool {
+	if p.kind != q.kind {
+		return p.kind < q.kind
+	}
+	if p.kind == kindExact || p.kind == kindRegex {
+		return false
+	}
+	if p.literals != q.literals {
+		return p.literals > q.literals
+	}
+	for i := 0; i < len(p.segments) && i < len(q.segments); i++ {
+		if a, b := p.segments[i].kind, q.segments[i].kind; a != b {
+			return a < b
+		}
+	}
+	return len(p.segments) > len(q.segments)
+}
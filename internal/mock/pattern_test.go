@@ -0,0 +1,125 @@
+package mock
+
+import (
+	"go-api-tester/internal/database"
+	"reflect"
+	"testing"
+)
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		params  map[string]string // nil 表示不匹配
+	}{
+		{"/users/123", "/users/123", map[string]string{}},
+		{"/users/123", "/users/1234", nil},
+		{"/users/:id", "/users/42", map[string]string{"id": "42"}},
+		{"/users/:id", "/users/", nil},
+		{"/users/:id", "/users/42/x", nil},
+		{"/users/{id}/posts/:pid", "/users/7/posts/9", map[string]string{"id": "7", "pid": "9"}},
+		{"/files/*", "/files/a", map[string]string{"1": "a"}},
+		{"/files/*", "/files/a/b", nil},
+		{"/files/*", "/files", nil},
+		{"/docs/*.md", "/docs/readme.md", map[string]string{}},
+		{"/docs/*.md", "/docs/a/readme.md", nil},
+		{"/static/**", "/static", map[string]string{"1": ""}},
+		{"/static/**", "/static/a/b/c", map[string]string{"1": "a/b/c"}},
+		{"/**/edit", "/edit", map[string]string{"1": ""}},
+		{"/**/edit", "/a/b/edit", map[string]string{"1": "a/b"}},
+		{"/**/edit", "/a/b/edit/x", nil},
+		// ** 回溯: 后面的段需要匹配剩余部分
+		{"/*/**/*.json", "/a/b/c/d.json", map[string]string{"1": "a", "2": "b/c"}},
+		{"/*/**/*.json", "/a/d.json", map[string]string{"1": "a", "2": ""}},
+		{"/*/**/*.json", "/d.json", nil},
+		{"/**/:id/detail", "/a/b/42/detail", map[string]string{"1": "a/b", "id": "42"}},
+		{"/a/*/*/b", "/a/x/y/b", map[string]string{"1": "x", "2": "y"}},
+		// 相邻的 ** 中前一个尽量少匹配
+		{"/**/**/x", "/a/b/x", map[string]string{"1": "", "2": "a/b"}},
+		{"/**/*/**", "/a", map[string]string{"1": "", "2": "a", "3": ""}},
+		{`~/v(\d+)/orders/(?P<oid>\d+)`, "/v2/orders/77", map[string]string{"1": "2", "oid": "77"}},
+		{`~/v(\d+)/orders/(?P<oid>\d+)`, "/v2/orders/77/x", nil},
+	}
+	for _, tt := range tests {
+		p, err := compilePattern(tt.pattern)
+		if err != nil {
+			t.Fatalf("compilePattern(%s): %v", tt.pattern, err)
+		}
+		params, ok := p.match(tt.path)
+		if !ok {
+			params = nil
+		}
+		if (tt.params == nil) != !ok || (ok && !reflect.DeepEqual(params, tt.params)) {
+			t.Errorf("%s match %s = %v (%v), want %v", tt.pattern, tt.path, params, ok, tt.params)
+		}
+	}
+}
+
+func TestValidatePattern(t *testing.T) {
+	for _, raw := range []string{"/users/:1id", "/users/{}", "/files/[*.json", "~/v(\\d+"} {
+		if ValidatePattern(raw) == nil {
+			t.Errorf("ValidatePattern(%s): expected error", raw)
+		}
+	}
+}
+
+func TestRulePrecedence(t *testing.T) {
+	tests := []struct {
+		path     string
+		patterns []string // 按规则 ID 升序
+		want     int      // 期望选中的下标
+	}{
+		{"/users/me", []string{"~/users/.*", "/users/*", "/users/:id", "/users/me"}, 3},
+		{"/users/42", []string{"~/users/.*", "/users/*", "/users/:id", "/users/me"}, 2},
+		{"/users/42", []string{"~/users/.*", "/users/**"}, 1},
+		{"/users/42/posts", []string{"/users/**", "/users/*/posts", "/users/:id/posts"}, 2},
+		// 同为通配时字面段多的优先
+		{"/a/b/c", []string{"/**", "/a/**", "/a/*/c"}, 2},
+		// 字面段相同时从左到右比较段的具体程度: 段内通配 > * > **
+		{"/a/b", []string{"/a/**", "/a/*"}, 1},
+		{"/files/x.json", []string{"/files/*", "/files/*.json"}, 1},
+		{"/x/y/z", []string{"/*/**", "/**/z"}, 1},
+		// 完全相同时取 ID 最小的规则
+		{"/t/1", []string{"/t/:id", "/t/{id}"}, 0},
+		{"/t/1", []string{"/other", "/t/:id"}, 1},
+	}
+	for _, tt := range tests {
+		rules := make([]*database.MockRule, len(tt.patterns))
+		for i, p := range tt.patterns {
+			rules[i] = &database.MockRule{ID: int64(9000 + i), PathPattern: p}
+		}
+		got, _ := matchRule(rules, tt.path)
+		if got != rules[tt.want] {
+			name := "<nil>"
+			if got != nil {
+				name = got.PathPattern
+			}
+			t.Errorf("%s: selected %s, want %s", tt.path, name, tt.patterns[tt.want])
+		}
+		for _, r := range rules {
+			ForgetRule(r.ID)
+		}
+	}
+}
+
+func TestRulePatternCache(t *testing.T) {
+	rule := &database.MockRule{ID: 9100, PathPattern: "/a/:id"}
+	p1, err := rulePattern(rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p2, _ := rulePattern(rule); p2 != p1 {
+		t.Error("pattern not reused from cache")
+	}
+
+	// 路径模式修改后重新编译
+	rule.PathPattern = "/b/:id"
+	if p3, _ := rulePattern(rule); p3 == p1 || p3.raw != "/b/:id" {
+		t.Errorf("pattern not recompiled after change: %q", p3.raw)
+	}
+
+	ForgetRule(rule.ID)
+	if _, ok := patternCache.Load(rule.ID); ok {
+		t.Error("ForgetRule did not evict the cached pattern")
+	}
+}
@@ -0,0 +1,170 @@
+package mock
+
+import (
+	"database/sql"
+	"fmt"
+	"go-api-tester/internal/database"
+	"sort"
+	"sync"
+)
+
+// StateStarted 场景的初始状态 (未访问过或重置后)
+const StateStarted = "Started"
+
+// Scenario 场景的当前状态与模板中 store 写入的数据
+type Scenario struct {
+	Name  string                 `json:"name"`
+	State string                 `json:"state"`
+	Data  map[string]interface{} `json:"data"`
+	Rules int                    `json:"rules"` // 使用该场景的规则数
+}
+
+type scenarioState struct {
+	state string
+	data  map[string]interface{}
+}
+
+// scenarioStore 场景状态保存在内存中，服务重启后回到初始状态
+type scenarioStore struct {
+	mu    sync.Mutex
+	items map[string]*scenarioState
+}
+
+var scenarios = &scenarioStore{items: make(map[string]*scenarioState)}
+
+// get 获取场景，不存在时创建，调用方需持有锁
+func (s *scenarioStore) get(name string) *scenarioState {
+	st, ok := s.items[name]
+	if !ok {
+		st = &scenarioState{state: StateStarted, data: make(map[string]interface{})}
+		s.items[name] = st
+	}
+	return st
+}
+
+// state 返回场景当前状态，只读取不创建
+func (s *scenarioStore) state(name string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.items[name]; ok {
+		return st.state
+	}
+	return StateStarted
+}
+
+// claim 场景处于 from 状态 (from 为空时不限) 时切换到 to
+// 检查与切换在同一把锁内完成，并发请求中只有一个能完成同一次切换；返回切换前的状态
+func (s *scenarioStore) claim(name, from, to string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	st := s.get(name)
+	prev := st.state
+	if from != "" && prev != from {
+		return prev, false
+	}
+	st.state = to
+	return prev, true
+}
+
+func (s *scenarioStore) load(name, key string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if st, ok := s.items[name]; ok {
+		if v, ok := st.data[key]; ok {
+			return v
+		}
+	}
+	return ""
+}
+
+func (s *scenarioStore) store(name, key string, v interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.get(name).data[key] = v
+}
+
+// snapshot 复制场景状态与数据，只读取不创建
+func (s *scenarioStore) snapshot(name string) Scenario {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sc := Scenario{Name: name, State: StateStarted, Data: make(map[string]interface{})}
+	if st, ok := s.items[name]; ok {
+		sc.State = st.state
+		for k, v := range st.data {
+			sc.Data[k] = v
+		}
+	}
+	return sc
+}
+
+// ListScenarios 列出规则中使用的场景及其状态 (按名称排序)
+func ListScenarios() ([]Scenario, error) {
+	names, err := database.GetMockScenarioNames()
+	if err != nil {
+		return nil, err
+	}
+	list := make([]Scenario, 0, len(names))
+	for name, count := range names {
+		sc := scenarios.snapshot(name)
+		sc.Rules = count
+		list = append(list, sc)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// GetScenario 获取单个场景的状态，没有规则使用该场景时返回 sql.ErrNoRows
+func GetScenario(name string) (Scenario, error) {
+	count, err := scenarioRules(name)
+	if err != nil {
+		return Scenario{}, err
+	}
+	sc := scenarios.snapshot(name)
+	sc.Rules = count
+	return sc, nil
+}
+
+// SetScenarioState 手动设置场景状态 (保留数据)，没有规则使用该场景时返回 sql.ErrNoRows
+func SetScenarioState(name, state string) error {
+	if state == "" {
+		return fmt.Errorf("state is required")
+	}
+	if _, err := scenarioRules(name); err != nil {
+		return err
+	}
+	scenarios.claim(name, "", state)
+	return nil
+}
+
+// scenarioRules 返回使用该场景的规则数
+func scenarioRules(name string) (int, error) {
+	names, err := database.GetMockScenarioNames()
+	if err != nil {
+		return 0, err
+	}
+	if names[name] == 0 {
+		return 0, sql.ErrNoRows
+	}
+	return names[name], nil
+}
+
+// ResetScenario 将场景恢复到初始状态并清空数据
+func ResetScenario(name string) {
+	scenarios.mu.Lock()
+	defer scenarios.mu.Unlock()
+	delete(scenarios.items, name)
+}
+
+// ResetScenarios 重置所有场景
+func ResetScenarios() {
+	scenarios.mu.Lock()
+	defer scenarios.mu.Unlock()
+	scenarios.items = make(map[string]*scenarioState)
+}
+
+func validateScenario(rule *database.MockRule) error {
+	if rule.Scenario == "" && (rule.RequiredState != "" || rule.NewState != "") {
+		return fmt.Errorf("required_state and new_state require a scenario")
+	}
+	return nil
+}
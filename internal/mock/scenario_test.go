@@ -0,0 +1,154 @@
+package mock
+
+import (
+	"database/sql"
+	"go-api-tester/internal/database"
+	"io"
+	"sync"
+	"testing"
+)
+
+func TestScenarioTransitions(t *testing.T) {
+	srv := setupMock(t,
+		&database.MockRule{PathPattern: "/job", ResponseBody: "fallback"},
+		&database.MockRule{PathPattern: "/job", Scenario: "job", RequiredState: StateStarted, NewState: "polled", StatusCode: 202, ResponseBody: "pending 1"},
+		&database.MockRule{PathPattern: "/job", Scenario: "job", RequiredState: "polled", NewState: "done", StatusCode: 202, ResponseBody: "pending 2"},
+		&database.MockRule{PathPattern: "/job", Scenario: "job", RequiredState: "done", ResponseBody: "done"},
+		&database.MockRule{PathPattern: "/job/cancel", Method: "POST", Scenario: "job", RequiredState: "polled", NewState: "cancelled", ResponseBody: "cancelled"},
+		&database.MockRule{PathPattern: "/job/state", Scenario: "job", Template: true, ResponseBody: "{{.scenario}}={{.state}}"},
+	)
+
+	// 场景未处于 required_state 时规则不参与匹配
+	if status, _, _ := call(t, srv, "POST", "/job/cancel", ""); status != 404 {
+		t.Errorf("cancel in Started: got %d, want 404", status)
+	}
+
+	steps := []struct {
+		status int
+		body   string
+	}{{202, "pending 1"}, {202, "pending 2"}, {200, "done"}, {200, "done"}}
+	for i, s := range steps {
+		if status, _, body := call(t, srv, "GET", "/job", ""); status != s.status || body != s.body {
+			t.Errorf("poll #%d: got %d %q, want %d %q", i+1, status, body, s.status, s.body)
+		}
+	}
+	if _, _, body := call(t, srv, "GET", "/job/state", ""); body != "job=done" {
+		t.Errorf("template state = %q", body)
+	}
+	if status, _, _ := call(t, srv, "POST", "/job/cancel", ""); status != 404 {
+		t.Errorf("cancel in done: got %d, want 404", status)
+	}
+
+	// 手动设置状态: 没有规则要求该状态时退回不属于场景的规则
+	if err := SetScenarioState("job", "paused"); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, body := call(t, srv, "GET", "/job", ""); body != "fallback" {
+		t.Errorf("paused: got %q, want fallback", body)
+	}
+
+	ResetScenario("job")
+	if _, _, body := call(t, srv, "GET", "/job", ""); body != "pending 1" {
+		t.Errorf("after reset: got %q", body)
+	}
+	if status, _, body := call(t, srv, "POST", "/job/cancel", ""); status != 200 || body != "cancelled" {
+		t.Errorf("cancel in polled: got %d %q", status, body)
+	}
+	if sc, err := GetScenario("job"); err != nil || sc.State != "cancelled" || sc.Rules != 5 {
+		t.Errorf("GetScenario = %+v, %v", sc, err)
+	}
+}
+
+func TestScenarioStoreLoad(t *testing.T) {
+	srv := setupMock(t,
+		&database.MockRule{PathPattern: "/items", Method: "POST", Scenario: "items", Template: true, StatusCode: 201,
+			ResponseBody: `{{store "last" .body.name}}{{store "count" 1}}created {{.body.name}}`},
+		&database.MockRule{PathPattern: "/items/last", Scenario: "items", Template: true,
+			ResponseBody: `{"name": "{{load "last"}}", "missing": "{{load "nope"}}"}`},
+		&database.MockRule{PathPattern: "/orphan", Template: true, ResponseBody: `{{store "k" "v"}}`},
+	)
+
+	if _, _, body := call(t, srv, "GET", "/items/last", ""); body != `{"name": "", "missing": ""}` {
+		t.Errorf("load before store: %q", body)
+	}
+	if status, _, body := call(t, srv, "POST", "/items", `{"name":"widget"}`); status != 201 || body != "created widget" {
+		t.Errorf("store: got %d %q", status, body)
+	}
+	if _, _, body := call(t, srv, "GET", "/items/last", ""); body != `{"name": "widget", "missing": ""}` {
+		t.Errorf("load after store: %q", body)
+	}
+	sc, err := GetScenario("items")
+	if err != nil || sc.Data["last"] != "widget" || sc.State != StateStarted {
+		t.Errorf("GetScenario = %+v, %v", sc, err)
+	}
+
+	// 规则不属于场景时 store 报错，且不会产生名称为空的场景
+	if status, _, _ := call(t, srv, "GET", "/orphan", ""); status != 500 {
+		t.Errorf("store without scenario: got %d, want 500", status)
+	}
+	if _, err := GetScenario(""); err != sql.ErrNoRows {
+		t.Errorf("GetScenario(\"\") err = %v, want sql.ErrNoRows", err)
+	}
+	list, err := ListScenarios()
+	if err != nil || len(list) != 1 || list[0].Name != "items" {
+		t.Errorf("ListScenarios = %+v, %v", list, err)
+	}
+}
+
+func TestScenarioUnknown(t *testing.T) {
+	setupMock(t, &database.MockRule{PathPattern: "/a", Scenario: "known"})
+	if _, err := GetScenario("unknown"); err != sql.ErrNoRows {
+		t.Errorf("GetScenario err = %v, want sql.ErrNoRows", err)
+	}
+	if err := SetScenarioState("unknown", "x"); err != sql.ErrNoRows {
+		t.Errorf("SetScenarioState err = %v, want sql.ErrNoRows", err)
+	}
+	if err := SetScenarioState("known", ""); err == nil {
+		t.Error("SetScenarioState with empty state: expected error")
+	}
+	scenarios.mu.Lock()
+	_, phantom := scenarios.items["unknown"]
+	scenarios.mu.Unlock()
+	if phantom {
+		t.Error("lookup of unknown scenario created state")
+	}
+}
+
+// TestScenarioConcurrentClaim 并发请求中只有一个能完成同一次状态切换
+func TestScenarioConcurrentClaim(t *testing.T) {
+	srv := setupMock(t,
+		&database.MockRule{PathPattern: "/once", Scenario: "race", RequiredState: StateStarted, NewState: "claimed", ResponseBody: "first"},
+		&database.MockRule{PathPattern: "/once", Scenario: "race", RequiredState: "claimed", ResponseBody: "later"},
+	)
+
+	const n = 50
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		counts = make(map[string]int)
+	)
+	start := make(chan struct{})
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			resp, err := srv.Client().Get(srv.URL + "/mock/once")
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			body, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			mu.Lock()
+			counts[string(body)]++
+			mu.Unlock()
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if counts["first"] != 1 || counts["later"] != n-1 {
+		t.Errorf("responses = %v, want exactly one first and %d later", counts, n-1)
+	}
+}
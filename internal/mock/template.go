@@ -0,0 +1,274 @@
+package mock
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/jsonpath"
+	mrand "math/rand/v2"
+	"net/http"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// 响应模板 (Go text/template)，规则设置 template 后 Body 与各响应头的值均按模板渲染
+//
+// 可用数据:
+//
+//	.params   路径参数，例如 {{.params.id}}
+//	.query    查询参数 (同名取第一个)，例如 {{.query.page}}
+//	.headers  请求头 (规范化名称)，例如 {{index .headers "X-Request-Id"}}
+//	.body     解析后的 JSON 请求体 (不是 JSON 时为 nil)，例如 {{.body.user.name}}
+//	.rawBody  原始请求体；.method、.path 请求方法与路径 (不含 /mock 前缀)
+//	.scenario、.state  规则所属的场景及其切换前的状态 (规则不属于场景时为空)
+//
+// 辅助函数:
+//
+//	uuid、randomInt min max、randomFloat min max、randomBool、randomString n、pick a b ...
+//	randomName、randomFirstName、randomLastName、randomEmail
+//	now、timestamp、timestampMs、addDays n t、addDuration "1h30m" t、formatDate layout t
+//	randomDate from to (YYYY-MM-DD)、seq name (按名称递增的序号，从 1 开始)
+//	jsonPath expr (在请求体上查询)、json v、upper、lower、default def v、until n
+//	store key value、load key  在规则所属场景中保存与读取数据 (例如 POST 保存、GET 返回)，规则不属于场景时报错
+
+// templateData 渲染模板时可访问的请求信息
+func templateData(r *http.Request, path string, body []byte, doc interface{}, params map[string]string) map[string]interface{} {
+	query := make(map[string]string)
+	for k, v := range r.URL.Query() {
+		if len(v) > 0 {
+			query[k] = v[0]
+		}
+	}
+	headers := make(map[string]string)
+	for k, v := range r.Header {
+		if len(v) > 0 {
+			headers[k] = v[0]
+		}
+	}
+	if params == nil {
+		params = make(map[string]string)
+	}
+	return map[string]interface{}{
+		"params":  params,
+		"query":   query,
+		"headers": headers,
+		"body":    doc,
+		"rawBody": string(body),
+		"method":  r.Method,
+		"path":    path,
+	}
+}
+
+// parseJSONBody 解析 JSON 请求体，为空或不是 JSON 时返回 nil
+func parseJSONBody(body []byte) interface{} {
+	var doc interface{}
+	if len(body) == 0 || json.Unmarshal(body, &doc) != nil {
+		return nil
+	}
+	return doc
+}
+
+// parseTemplate 解析模板文本
+func parseTemplate(name, text string, data map[string]interface{}) (*template.Template, error) {
+	return template.New(name).Option("missingkey=zero").Funcs(templateFuncs(data)).Parse(text)
+}
+
+// renderTemplate 渲染模板文本，不包含 {{ 时原样返回
+func renderTemplate(name, text string, data map[string]interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := parseTemplate(name, text, data)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+func templateFuncs(data map[string]interface{}) template.FuncMap {
+	return template.FuncMap{
+		"uuid":        newUUID,
+		"randomInt":   randomInt,
+		"randomFloat": func(min, max float64) float64 { return min + mrand.Float64()*(max-min) },
+		"randomBool":  func() bool { return mrand.IntN(2) == 1 },
+		"randomString": func(n int) string {
+			const letters = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+			b := make([]byte, max(n, 0))
+			for i := range b {
+				b[i] = letters[mrand.IntN(len(letters))]
+			}
+			return string(b)
+		},
+		"pick": func(items ...interface{}) interface{} {
+			if len(items) == 0 {
+				return ""
+			}
+			return items[mrand.IntN(len(items))]
+		},
+		"randomFirstName": func() string { return firstNames[mrand.IntN(len(firstNames))] },
+		"randomLastName":  func() string { return lastNames[mrand.IntN(len(lastNames))] },
+		"randomName": func() string {
+			return firstNames[mrand.IntN(len(firstNames))] + " " + lastNames[mrand.IntN(len(lastNames))]
+		},
+		"randomEmail": func() string {
+			return fmt.Sprintf("%s.%s%d@%s", strings.ToLower(firstNames[mrand.IntN(len(firstNames))]),
+				strings.ToLower(lastNames[mrand.IntN(len(lastNames))]), mrand.IntN(100), emailDomains[mrand.IntN(len(emailDomains))])
+		},
+
+		"now":         time.Now,
+		"timestamp":   func() int64 { return time.Now().Unix() },
+		"timestampMs": func() int64 { return time.Now().UnixMilli() },
+		"addDays":     func(n int, t time.Time) time.Time { return t.AddDate(0, 0, n) },
+		"addDuration": func(d string, t time.Time) (time.Time, error) {
+			dur, err := time.ParseDuration(d)
+			return t.Add(dur), err
+		},
+		"formatDate": func(layout string, t time.Time) string { return t.Format(layout) },
+		"randomDate": randomDate,
+		"seq":        sequences.next,
+
+		"store": func(key string, v interface{}) (string, error) {
+			name := scenarioName(data)
+			if name == "" {
+				return "", fmt.Errorf("store requires the rule to belong to a scenario")
+			}
+			scenarios.store(name, key, v)
+			return "", nil
+		},
+		"load": func(key string) (interface{}, error) {
+			name := scenarioName(data)
+			if name == "" {
+				return nil, fmt.Errorf("load requires the rule to belong to a scenario")
+			}
+			return scenarios.load(name, key), nil
+		},
+
+		"jsonPath": func(expr string) interface{} {
+			v, err := jsonpath.Get(data["body"], expr)
+			if err != nil {
+				return ""
+			}
+			return v
+		},
+		"json": func(v interface{}) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"upper": strings.ToUpper,
+		"lower": strings.ToLower,
+		"default": func(def, v interface{}) interface{} {
+			if v == nil || v == "" {
+				return def
+			}
+			return v
+		},
+		"until": func(n int) []int {
+			list := make([]int, max(n, 0))
+			for i := range list {
+				list[i] = i
+			}
+			return list
+		},
+	}
+}
+
+// validateTemplates 规则启用模板时检查默认响应与条件响应的模板语法
+func validateTemplates(rule *database.MockRule) error {
+	if !rule.Template {
+		return nil
+	}
+	check := func(where, body string, headers map[string]string) error {
+		if _, err := parseTemplate("body", body, nil); err != nil {
+			return fmt.Errorf("%sinvalid body template: %v", where, err)
+		}
+		for k, v := range headers {
+			if _, err := parseTemplate("header "+k, v, nil); err != nil {
+				return fmt.Errorf("%sinvalid template in header %s: %v", where, k, err)
+			}
+		}
+		return nil
+	}
+	if err := check("", rule.ResponseBody, rule.ResponseHeaders); err != nil {
+		return err
+	}
+	for i, v := range rule.Responses {
+		if err := check(fmt.Sprintf("response #%d: ", i+1), v.Body, v.Headers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func scenarioName(data map[string]interface{}) string {
+	name, _ := data["scenario"].(string)
+	return name
+}
+
+// newUUID 生成随机 (v4) UUID
+func newUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
+
+// randomInt 返回 [min, max] 范围内的随机整数
+func randomInt(min, max int) int {
+	if max <= min {
+		return min
+	}
+	return min + mrand.IntN(max-min+1)
+}
+
+// randomDate 返回 [from, to] (YYYY-MM-DD) 之间的随机时间
+func randomDate(from, to string) (time.Time, error) {
+	start, err := time.ParseInLocation(time.DateOnly, from, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	end, err := time.ParseInLocation(time.DateOnly, to, time.Local)
+	if err != nil {
+		return time.Time{}, err
+	}
+	span := end.AddDate(0, 0, 1).Sub(start)
+	if span <= 0 {
+		return start, nil
+	}
+	return start.Add(time.Duration(mrand.Int64N(int64(span)))), nil
+}
+
+// sequenceStore 模板中 seq 使用的计数器，服务运行期间持续递增
+type sequenceStore struct {
+	mu     sync.Mutex
+	values map[string]int64
+}
+
+var sequences = &sequenceStore{values: make(map[string]int64)}
+
+func (s *sequenceStore) next(name string) int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[name]++
+	return s.values[name]
+}
+
+var (
+	firstNames = []string{
+		"James", "Mary", "John", "Patricia", "Robert", "Jennifer", "Michael", "Linda", "William", "Elizabeth",
+		"David", "Barbara", "Richard", "Susan", "Joseph", "Jessica", "Thomas", "Sarah", "Daniel", "Karen",
+	}
+	lastNames = []string{
+		"Smith", "Johnson", "Williams", "Brown", "Jones", "Garcia", "Miller", "Davis", "Rodriguez", "Martinez",
+		"Wilson", "Anderson", "Taylor", "Thomas", "Moore", "Jackson", "Martin", "Lee", "Thompson", "White",
+	}
+	emailDomains = []string{"example.com", "example.org", "example.net"}
+)
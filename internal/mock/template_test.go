@@ -0,0 +1,53 @@
+package mock
+
+import (
+	"go-api-tester/internal/database"
+	"strings"
+	"testing"
+)
+
+func TestTemplateOptIn(t *testing.T) {
+	literal := `{"greeting": "Hello {{name}}", "raw": "{{.params.id}}"}`
+	srv := setupMock(t,
+		&database.MockRule{PathPattern: "/literal/:id", ResponseBody: literal, ResponseHeaders: map[string]string{"X-Raw": "{{name}}"}},
+		&database.MockRule{PathPattern: "/tmpl/:id", Template: true,
+			ResponseBody:    `{"id": "{{.params.id}}", "page": "{{.query.page}}", "name": "{{.body.name}}"}`,
+			ResponseHeaders: map[string]string{"X-Id": "{{.params.id}}"}},
+	)
+
+	// 未启用模板时 {{ 原样返回
+	status, h, body := call(t, srv, "GET", "/literal/7", "")
+	if status != 200 || body != literal || h.Get("X-Raw") != "{{name}}" {
+		t.Errorf("literal rule = %d %q %q", status, h.Get("X-Raw"), body)
+	}
+
+	status, h, body = call(t, srv, "GET", "/tmpl/42?page=3", `{"name":"Ann"}`)
+	if want := `{"id": "42", "page": "3", "name": "Ann"}`; status != 200 || body != want || h.Get("X-Id") != "42" {
+		t.Errorf("template rule = %d %q %q, want %q", status, h.Get("X-Id"), body, want)
+	}
+}
+
+func TestValidateTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    database.MockRule
+		errPart string
+	}{
+		{"literal braces without template", database.MockRule{PathPattern: "/a", ResponseBody: "{{name}}"}, ""},
+		{"valid template", database.MockRule{PathPattern: "/a", Template: true, ResponseBody: "{{uuid}} {{.query.x}}"}, ""},
+		{"unknown function", database.MockRule{PathPattern: "/a", Template: true, ResponseBody: "{{name}}"}, "body template"},
+		{"unclosed action", database.MockRule{PathPattern: "/a", Template: true, ResponseBody: "{{if .query.x}}"}, "body template"},
+		{"bad header", database.MockRule{PathPattern: "/a", Template: true, ResponseHeaders: map[string]string{"X-A": "{{.x"}}, "header X-A"},
+		{"bad response variant", database.MockRule{PathPattern: "/a", Template: true,
+			Responses: []database.MockResponse{{Body: "ok"}, {Body: "{{end}}"}}}, "response #2"},
+	}
+	for _, tt := range tests {
+		err := ValidateRule(&tt.rule)
+		switch {
+		case tt.errPart == "" && err != nil:
+			t.Errorf("%s: unexpected error %v", tt.name, err)
+		case tt.errPart != "" && (err == nil || !strings.Contains(err.Error(), tt.errPart)):
+			t.Errorf("%s: err = %v, want containing %q", tt.name, err, tt.errPart)
+		}
+	}
+}
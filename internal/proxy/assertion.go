@@ -0,0 +1,100 @@
+package proxy
+
+import (
+	"fmt"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/jsonpath"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// evaluateAssertions 依次评估启用的断言，禁用的断言不出现在结果中
+func evaluateAssertions(list []database.Assertion, resp ProxyResponse) []AssertionResult {
+	var results []AssertionResult
+	for _, a := range list {
+		if !a.Enabled {
+			continue
+		}
+		result := AssertionResult{Assertion: a}
+		if resp.Error != "" {
+			result.Message = "请求失败: " + resp.Error
+		} else {
+			result.Passed, result.Actual, result.Message = evaluateAssertion(a, resp)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// evaluateAssertion 评估单条断言，返回 (是否通过, 实际值, 失败原因)
+func evaluateAssertion(a database.Assertion, resp ProxyResponse) (bool, string, string) {
+	switch a.Type {
+	case database.AssertStatusEquals:
+		actual := strconv.Itoa(resp.StatusCode)
+		expected := strings.TrimSpace(a.Expected)
+		if actual == expected {
+			return true, actual, ""
+		}
+		return false, actual, fmt.Sprintf("期望状态码 %s，实际为 %s", expected, actual)
+
+	case database.AssertHeaderPresent:
+		name := http.CanonicalHeaderKey(strings.TrimSpace(a.Target))
+		values, ok := resp.Headers[name]
+		if !ok {
+			return false, "", fmt.Sprintf("响应头 %s 不存在", name)
+		}
+		actual := strings.Join(values, ", ")
+		// 同时给出 Expected 时要求值相等
+		if a.Expected != "" && actual != a.Expected {
+			return false, actual, fmt.Sprintf("响应头 %s 期望为 %q", name, a.Expected)
+		}
+		return true, actual, ""
+
+	case database.AssertJSONPathEquals, database.AssertJSONPathMatches:
+		if resp.IsBinary {
+			return false, "", "响应为二进制内容，无法解析 JSON"
+		}
+		val, err := jsonpath.Lookup([]byte(resp.Body), a.Target)
+		if err != nil {
+			return false, "", err.Error()
+		}
+		actual := jsonpath.Stringify(val)
+		if a.Type == database.AssertJSONPathEquals {
+			if actual == a.Expected {
+				return true, actual, ""
+			}
+			return false, actual, fmt.Sprintf("%s 期望为 %q", a.Target, a.Expected)
+		}
+		re, err := regexp.Compile(a.Expected)
+		if err != nil {
+			return false, actual, "无效的正则表达式: " + err.Error()
+		}
+		if re.MatchString(actual) {
+			return true, actual, ""
+		}
+		return false, actual, fmt.Sprintf("%s 不匹配 /%s/", a.Target, a.Expected)
+
+	case database.AssertResponseTimeBelow:
+		limit, err := strconv.ParseInt(strings.TrimSpace(a.Expected), 10, 64)
+		if err != nil {
+			return false, "", "无效的毫秒数: " + a.Expected
+		}
+		actual := strconv.FormatInt(resp.TimeMs, 10)
+		if resp.TimeMs < limit {
+			return true, actual, ""
+		}
+		return false, actual, fmt.Sprintf("响应耗时 %s ms，超过 %d ms", actual, limit)
+
+	case database.AssertBodyContains:
+		if resp.IsBinary {
+			return false, "", "响应为二进制内容"
+		}
+		if strings.Contains(resp.Body, a.Expected) {
+			return true, "", ""
+		}
+		return false, "", fmt.Sprintf("响应体不包含 %q", a.Expected)
+	}
+	return false, "", "未知的断言类型: " + a.Type
+}
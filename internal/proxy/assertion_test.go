@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"go-api-tester/internal/database"
+	"testing"
+)
+
+func TestEvaluateAssertion(t *testing.T) {
+	resp := ProxyResponse{
+		StatusCode: 201,
+		Headers:    map[string][]string{"Content-Type": {"application/json"}},
+		Body:       `{"id": 9007199254740993, "amount": 12.50, "user": {"name": "Ann"}}`,
+		TimeMs:     120,
+	}
+	tests := []struct {
+		name string
+		a    database.Assertion
+		pass bool
+	}{
+		{"status", database.Assertion{Type: database.AssertStatusEquals, Expected: "201"}, true},
+		{"status mismatch", database.Assertion{Type: database.AssertStatusEquals, Expected: "200"}, false},
+		{"header present", database.Assertion{Type: database.AssertHeaderPresent, Target: "content-type"}, true},
+		{"header value", database.Assertion{Type: database.AssertHeaderPresent, Target: "Content-Type", Expected: "text/plain"}, false},
+		// 超过 2^53 的整数按原文比较，不会被舍入为 9007199254740992
+		{"big id equals", database.Assertion{Type: database.AssertJSONPathEquals, Target: "$.id", Expected: "9007199254740993"}, true},
+		{"big id rounded", database.Assertion{Type: database.AssertJSONPathEquals, Target: "$.id", Expected: "9007199254740992"}, false},
+		{"decimal verbatim", database.Assertion{Type: database.AssertJSONPathEquals, Target: "$.amount", Expected: "12.50"}, true},
+		{"string equals", database.Assertion{Type: database.AssertJSONPathEquals, Target: "$.user.name", Expected: "Ann"}, true},
+		{"matches", database.Assertion{Type: database.AssertJSONPathMatches, Target: "$.id", Expected: `^9007199254740993$`}, true},
+		{"missing path", database.Assertion{Type: database.AssertJSONPathEquals, Target: "$.nope", Expected: "x"}, false},
+		{"time below", database.Assertion{Type: database.AssertResponseTimeBelow, Expected: "200"}, true},
+		{"time above", database.Assertion{Type: database.AssertResponseTimeBelow, Expected: "100"}, false},
+		{"body contains", database.Assertion{Type: database.AssertBodyContains, Expected: `"Ann"`}, true},
+	}
+	for _, tt := range tests {
+		if pass, actual, msg := evaluateAssertion(tt.a, resp); pass != tt.pass {
+			t.Errorf("%s: passed = %v (actual %q, %s), want %v", tt.name, pass, actual, msg, tt.pass)
+		}
+	}
+}
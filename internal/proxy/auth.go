@@ -0,0 +1,472 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"go-api-tester/internal/database"
+	"hash"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 认证类型
+const (
+	AuthBasic   = "basic"
+	AuthBearer  = "bearer"
+	AuthAPIKey  = "apikey" // APIKey: key, value, in (header | query，默认 header)
+	AuthDigest  = "digest" // Digest: username, password，收到 401 质询后自动重试
+	AuthHMAC    = "hmac"   // HMAC: 见 signHMAC
+	AuthOAuth2  = "oauth2" // OAuth 2.0: 见 oauth2.go，令牌缓存在数据库中并自动刷新
+	AuthAWSV4   = "awsv4"  // AWS Signature v4: 见 signAWSV4
+	AuthNone    = "none"
+	AuthInherit = "inherit" // 沿用所在分组的认证，见 resolveInheritedAuth
+)
+
+// applyAuth 按认证类型设置请求头或查询参数 (Digest 在 Transport 中处理，OAuth 2.0 见 applyOAuth2)
+func applyAuth(r *http.Request, auth AuthConfig) error {
+	switch auth.Type {
+	case AuthBasic:
+		r.SetBasicAuth(auth.Basic["username"], auth.Basic["password"])
+	case AuthBearer:
+		r.Header.Set("Authorization", "Bearer "+auth.Bearer["token"])
+	case AuthAPIKey:
+		key, value := auth.APIKey["key"], auth.APIKey["value"]
+		if key == "" {
+			return fmt.Errorf("API Key 缺少 key")
+		}
+		switch auth.APIKey["in"] {
+		case "", "header":
+			r.Header.Set(key, value)
+		case "query":
+			q := r.URL.Query()
+			q.Set(key, value)
+			r.URL.RawQuery = q.Encode()
+		default:
+			return fmt.Errorf("API Key 位置只能是 header 或 query")
+		}
+	case AuthHMAC:
+		return signHMAC(r, auth.HMAC, time.Now())
+	case AuthAWSV4:
+		return signAWSV4(r, auth.AWSV4, time.Now())
+	}
+	return nil
+}
+
+// resolveInheritedAuth 将 inherit 认证替换为所在分组链上最近一个配置了认证的分组的认证
+// 分组链上均未配置认证时视为无认证；返回实际来源，认证类型不是 inherit 时返回 nil
+func resolveInheritedAuth(req *ProxyRequest) (*AuthSource, error) {
+	if req.Auth.Type != AuthInherit {
+		return nil, nil
+	}
+	req.Auth = AuthConfig{Type: AuthNone}
+	source := &AuthSource{Type: AuthNone}
+	if req.CollectionID == 0 || database.DB == nil {
+		return source, nil
+	}
+
+	path, err := database.GetCollectionPath(req.CollectionID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(path) - 1; i >= 0; i-- {
+		c := path[i]
+		if c.Auth == nil || c.Auth.Type == "" || c.Auth.Type == AuthInherit {
+			continue
+		}
+		req.Auth = fromSavedAuth(*c.Auth)
+		return &AuthSource{CollectionID: c.ID, CollectionName: c.Name, Type: c.Auth.Type}, nil
+	}
+	return source, nil
+}
+
+// readBody 读取请求体用于签名或摘要，不影响实际发送 (通过 GetBody 重新获取)
+func readBody(r *http.Request) ([]byte, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, nil
+	}
+	if r.GetBody == nil {
+		return nil, fmt.Errorf("请求体无法重复读取")
+	}
+	body, err := r.GetBody()
+	if err != nil {
+		return nil, err
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
+
+// ---------------------------------------------------------------------------
+// HMAC 签名
+// ---------------------------------------------------------------------------
+
+// 默认的待签名字符串模板
+const defaultHMACCanonical = "{method}\n{path}\n{query}\n{timestamp}\n{body_sha256}"
+
+// signHMAC 按配置生成签名并写入请求头
+//
+// 配置项 (均可选，secret 除外):
+//   - secret: 密钥；secret_encoding 为 base64 / hex 时先解码
+//   - key_id: 密钥标识，可在 canonical 与 header_format 中引用
+//   - algorithm: sha256 (默认) | sha1 | sha512 | md5
+//   - encoding: 签名编码 hex (默认) | base64
+//   - canonical: 待签名字符串模板，默认 defaultHMACCanonical，占位符:
+//     {method} {host} {path} {query} (按键排序) {timestamp} {nonce} {key_id}
+//     {body} {body_sha256} {body_md5} {headers} (signed_headers 的 name:value 行)
+//   - signed_headers: 逗号分隔的参与签名的请求头
+//   - header: 签名写入的请求头，默认 Authorization
+//   - header_format: 请求头的值，默认 "HMAC {key_id}:{signature}"，可使用所有占位符与 {signature}
+//   - timestamp_format: unix (默认) | unix_ms | rfc3339 | http
+//   - timestamp_header / nonce_header: 模板使用时间戳或随机数时写入的请求头，默认 X-Timestamp / X-Nonce
+func signHMAC(r *http.Request, cfg map[string]string, now time.Time) error {
+	secret, err := decodeSecret(cfg["secret"], cfg["secret_encoding"])
+	if err != nil {
+		return err
+	}
+	newHash, err := hmacHash(cfg["algorithm"])
+	if err != nil {
+		return err
+	}
+
+	canonical := valueOr(cfg["canonical"], defaultHMACCanonical)
+	headerFormat := valueOr(cfg["header_format"], "HMAC {key_id}:{signature}")
+	templates := canonical + headerFormat
+
+	values := map[string]string{
+		"method": r.Method,
+		"host":   r.URL.Host,
+		"path":   valueOr(r.URL.EscapedPath(), "/"),
+		"query":  canonicalQuery(r.URL.Query()),
+		"key_id": cfg["key_id"],
+	}
+
+	if strings.Contains(templates, "{timestamp}") {
+		ts, err := formatTimestamp(now, cfg["timestamp_format"])
+		if err != nil {
+			return err
+		}
+		values["timestamp"] = ts
+		r.Header.Set(valueOr(cfg["timestamp_header"], "X-Timestamp"), ts)
+	}
+	if strings.Contains(templates, "{nonce}") {
+		values["nonce"] = randomHex(16)
+		r.Header.Set(valueOr(cfg["nonce_header"], "X-Nonce"), values["nonce"])
+	}
+	if strings.Contains(canonical, "{body") {
+		body, err := readBody(r)
+		if err != nil {
+			return fmt.Errorf("HMAC 签名读取请求体失败: %v", err)
+		}
+		sum := sha256.Sum256(body)
+		md := md5.Sum(body)
+		values["body"] = string(body)
+		values["body_sha256"] = hex.EncodeToString(sum[:])
+		values["body_md5"] = hex.EncodeToString(md[:])
+	}
+	if strings.Contains(canonical, "{headers}") {
+		values["headers"] = canonicalHeaders(r, cfg["signed_headers"])
+	}
+
+	mac := hmac.New(newHash, secret)
+	mac.Write([]byte(fillTemplate(canonical, values)))
+	sum := mac.Sum(nil)
+	switch cfg["encoding"] {
+	case "", "hex":
+		values["signature"] = hex.EncodeToString(sum)
+	case "base64":
+		values["signature"] = base64.StdEncoding.EncodeToString(sum)
+	default:
+		return fmt.Errorf("不支持的签名编码: %s", cfg["encoding"])
+	}
+
+	r.Header.Set(valueOr(cfg["header"], "Authorization"), fillTemplate(headerFormat, values))
+	return nil
+}
+
+func decodeSecret(secret, encoding string) ([]byte, error) {
+	if secret == "" {
+		return nil, fmt.Errorf("HMAC 缺少 secret")
+	}
+	switch encoding {
+	case "", "raw":
+		return []byte(secret), nil
+	case "base64":
+		return base64.StdEncoding.DecodeString(secret)
+	case "hex":
+		return hex.DecodeString(secret)
+	default:
+		return nil, fmt.Errorf("不支持的 secret 编码: %s", encoding)
+	}
+}
+
+func hmacHash(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "", "sha256":
+		return sha256.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "sha512":
+		return sha512.New, nil
+	case "md5":
+		return md5.New, nil
+	default:
+		return nil, fmt.Errorf("不支持的 HMAC 算法: %s", algorithm)
+	}
+}
+
+func formatTimestamp(now time.Time, format string) (string, error) {
+	switch format {
+	case "", "unix":
+		return strconv.FormatInt(now.Unix(), 10), nil
+	case "unix_ms":
+		return strconv.FormatInt(now.UnixMilli(), 10), nil
+	case "rfc3339":
+		return now.UTC().Format(time.RFC3339), nil
+	case "http":
+		return now.UTC().Format(http.TimeFormat), nil
+	default:
+		return "", fmt.Errorf("不支持的时间戳格式: %s", format)
+	}
+}
+
+// canonicalQuery 按键 (及值) 排序后编码查询参数
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		vals := append([]string(nil), q[k]...)
+		sort.Strings(vals)
+		for _, v := range vals {
+			parts = append(parts, url.QueryEscape(k)+"="+url.QueryEscape(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// canonicalHeaders 生成 "name:value" 行 (名称小写，按配置顺序)
+func canonicalHeaders(r *http.Request, signed string) string {
+	var lines []string
+	for _, name := range strings.Split(signed, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.URL.Host
+		}
+		lines = append(lines, name+":"+strings.TrimSpace(value))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func fillTemplate(tpl string, values map[string]string) string {
+	pairs := make([]string, 0, len(values)*2)
+	for k, v := range values {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(tpl)
+}
+
+func valueOr(v, def string) string {
+	if v == "" {
+		return def
+	}
+	return v
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ---------------------------------------------------------------------------
+// Digest 认证 (RFC 7616)
+// ---------------------------------------------------------------------------
+
+// digestTransport 收到 Digest 质询 (401 + WWW-Authenticate: Digest) 后计算响应并重试一次
+type digestTransport struct {
+	base     http.RoundTripper
+	username string
+	password string
+}
+
+func (t *digestTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(r)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	challenge := findDigestChallenge(resp.Header.Values("WWW-Authenticate"))
+	if challenge == nil {
+		return resp, nil
+	}
+
+	retry := r.Clone(r.Context())
+	var body []byte
+	if r.Body != nil && r.Body != http.NoBody {
+		if r.GetBody == nil {
+			return resp, nil // 请求体无法重放，返回原始 401 响应
+		}
+		if body, err = readBody(r); err != nil {
+			return resp, nil
+		}
+		retry.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	authorization, err := digestAuthorization(challenge, t.username, t.password, r.Method, r.URL.RequestURI(), body, randomHex(8))
+	if err != nil {
+		resp.Body.Close()
+		return nil, err
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	retry.Header.Set("Authorization", authorization)
+	return t.base.RoundTrip(retry)
+}
+
+func findDigestChallenge(values []string) map[string]string {
+	for _, v := range values {
+		if len(v) > 7 && strings.EqualFold(v[:7], "Digest ") {
+			return parseAuthParams(v[7:])
+		}
+	}
+	return nil
+}
+
+// parseAuthParams 解析 key=value, key="quoted value" 形式的参数
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for s = strings.TrimSpace(s); s != ""; s = strings.TrimSpace(s) {
+		eq := strings.IndexByte(s, '=')
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = strings.TrimSpace(s[eq+1:])
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' && i+1 < len(s) {
+					i++
+				}
+				b.WriteByte(s[i])
+			}
+			value = b.String()
+			s = s[min(i+1, len(s)):]
+		} else {
+			end := strings.IndexByte(s, ',')
+			if end < 0 {
+				end = len(s)
+			}
+			value = strings.TrimSpace(s[:end])
+			s = s[end:]
+		}
+		params[key] = value
+		s = strings.TrimPrefix(strings.TrimSpace(s), ",")
+	}
+	return params
+}
+
+// digestAuthorization 按质询计算 Authorization 请求头，cnonce 由调用方生成
+func digestAuthorization(c map[string]string, username, password, method, uri string, body []byte, cnonce string) (string, error) {
+	algorithm := c["algorithm"]
+	if algorithm == "" {
+		algorithm = "MD5"
+	}
+	var h func([]byte) string
+	switch strings.TrimSuffix(strings.ToUpper(algorithm), "-SESS") {
+	case "MD5":
+		h = func(b []byte) string { s := md5.Sum(b); return hex.EncodeToString(s[:]) }
+	case "SHA-256":
+		h = func(b []byte) string { s := sha256.Sum256(b); return hex.EncodeToString(s[:]) }
+	case "SHA-512-256":
+		h = func(b []byte) string { s := sha512.Sum512_256(b); return hex.EncodeToString(s[:]) }
+	default:
+		return "", fmt.Errorf("不支持的 Digest 算法: %s", algorithm)
+	}
+
+	realm, nonce := c["realm"], c["nonce"]
+	nc := "00000001"
+
+	ha1 := h([]byte(username + ":" + realm + ":" + password))
+	if strings.HasSuffix(strings.ToUpper(algorithm), "-SESS") {
+		ha1 = h([]byte(ha1 + ":" + nonce + ":" + cnonce))
+	}
+
+	// qop 优先选择 auth，服务端只支持 auth-int 时对请求体做摘要
+	qop := ""
+	for _, q := range strings.Split(c["qop"], ",") {
+		q = strings.TrimSpace(q)
+		if q == "auth" {
+			qop = q
+			break
+		}
+		if q == "auth-int" {
+			qop = q
+		}
+	}
+
+	ha2 := h([]byte(method + ":" + uri))
+	if qop == "auth-int" {
+		ha2 = h([]byte(method + ":" + uri + ":" + h(body)))
+	}
+
+	var response string
+	if qop == "" {
+		response = h([]byte(ha1 + ":" + nonce + ":" + ha2))
+	} else {
+		response = h([]byte(ha1 + ":" + nonce + ":" + nc + ":" + cnonce + ":" + qop + ":" + ha2))
+	}
+
+	parts := []string{
+		"username=" + quoteString(username),
+		"realm=" + quoteString(realm),
+		"nonce=" + quoteString(nonce),
+		"uri=" + quoteString(uri),
+		"response=" + quoteString(response),
+		"algorithm=" + algorithm,
+	}
+	if qop != "" {
+		parts = append(parts, "qop="+qop, "nc="+nc, "cnonce="+quoteString(cnonce))
+	}
+	if opaque, ok := c["opaque"]; ok {
+		parts = append(parts, "opaque="+quoteString(opaque))
+	}
+	return "Digest " + strings.Join(parts, ", "), nil
+}
+
+// quoteString 生成 RFC 7230 quoted-string，只转义 " 与 \，其余字符 (包括非 ASCII) 原样保留
+func quoteString(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
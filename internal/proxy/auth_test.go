@@ -0,0 +1,176 @@
+package proxy
+
+import (
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDigestAuthorizationKnownAnswers(t *testing.T) {
+	rfc2617 := map[string]string{"realm": "testrealm@host.com", "nonce": "dcd98b7102dd2f0e8b11d0f600bfb0c093", "opaque": "5ccc069c403ebaf9f0171e9517f40e41"}
+	rfc7616 := map[string]string{"realm": "http-auth@example.org", "nonce": "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v", "opaque": "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"}
+	with := func(base map[string]string, kv ...string) map[string]string {
+		c := make(map[string]string, len(base)+len(kv)/2)
+		for k, v := range base {
+			c[k] = v
+		}
+		for i := 0; i < len(kv); i += 2 {
+			c[kv[i]] = kv[i+1]
+		}
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		challenge map[string]string
+		password  string
+		method    string
+		body      string
+		cnonce    string
+		response  string
+	}{
+		// RFC 2617 3.5
+		{"rfc2617 auth", with(rfc2617, "qop", "auth,auth-int"), "Circle Of Life", "GET", "", "0a4f113b", "6629fae49393a05397450978507c4ef1"},
+		// RFC 7616 3.9.1
+		{"rfc7616 md5", with(rfc7616, "qop", "auth, auth-int", "algorithm", "MD5"), "Circle of Life", "GET", "", "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ", "8ca523f5e9506fed4657c9700eebdbec"},
+		{"rfc7616 sha-256", with(rfc7616, "qop", "auth, auth-int", "algorithm", "SHA-256"), "Circle of Life", "GET", "", "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ", "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+		{"auth-int", with(rfc2617, "qop", "auth-int"), "Circle Of Life", "POST", `{"a":1}`, "0a4f113b", "be2cf5d41341309a658f2ea076ff1211"},
+		{"md5-sess", with(rfc2617, "qop", "auth", "algorithm", "MD5-sess"), "Circle Of Life", "GET", "", "0a4f113b", "8e3825c57e897f5a0dec6c2d4e5059d0"},
+		{"no qop", rfc2617, "Circle Of Life", "GET", "", "0a4f113b", "670fd8c2df070c60b045671b8b24ff02"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := digestAuthorization(tt.challenge, "Mufasa", tt.password, tt.method, "/dir/index.html", []byte(tt.body), tt.cnonce)
+			if err != nil {
+				t.Fatalf("digestAuthorization: %v", err)
+			}
+			params := parseAuthParams(strings.TrimPrefix(got, "Digest "))
+			if params["response"] != tt.response {
+				t.Errorf("response = %s, want %s\n%s", params["response"], tt.response, got)
+			}
+			if params["opaque"] != tt.challenge["opaque"] || params["uri"] != "/dir/index.html" || params["username"] != "Mufasa" {
+				t.Errorf("params = %v", params)
+			}
+			if _, hasQop := tt.challenge["qop"]; hasQop != (params["cnonce"] == tt.cnonce && params["nc"] == "00000001") {
+				t.Errorf("qop params = %v", params)
+			}
+		})
+	}
+
+	if _, err := digestAuthorization(with(rfc2617, "algorithm", "SHA-1"), "u", "p", "GET", "/", nil, "c"); err == nil {
+		t.Error("expected error for unsupported algorithm")
+	}
+}
+
+// TestDigestTransport 服务端只支持 auth-int，重试时需要重放请求体
+func TestDigestQuotedString(t *testing.T) {
+	if got := quoteString(`a"b\c ü`); got != `"a\"b\\c ü"` {
+		t.Errorf("quoteString = %s", got)
+	}
+
+	// 制表符与非 ASCII 字符原样传输 (%q 会把制表符写成 \t，服务端按 quoted-pair 还原为 t)
+	challenge := map[string]string{"realm": "r\"1\t2", "nonce": "n", "qop": "auth", "opaque": `o\p`}
+	header, err := digestAuthorization(challenge, `Jürgen "JJ"`, "pw", "GET", "/a?b=c", nil, "cn")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(header, `username="Jürgen \"JJ\""`) {
+		t.Errorf("header = %s", header)
+	}
+	params := parseAuthParams(strings.TrimPrefix(header, "Digest "))
+	for k, want := range map[string]string{"username": `Jürgen "JJ"`, "realm": "r\"1\t2", "opaque": `o\p`, "uri": "/a?b=c", "cnonce": "cn"} {
+		if params[k] != want {
+			t.Errorf("%s = %q, want %q", k, params[k], want)
+		}
+	}
+}
+
+func TestDigestTransport(t *testing.T) {
+	h := func(s string) string { sum := md5.Sum([]byte(s)); return hex.EncodeToString(sum[:]) }
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		auth := r.Header.Get("Authorization")
+		if !strings.HasPrefix(auth, "Digest ") {
+			w.Header().Set("WWW-Authenticate", `Digest realm="test", nonce="abc123", qop="auth-int", opaque="xyz"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		p := parseAuthParams(auth[7:])
+		ha1 := h("user:test:pass")
+		ha2 := h(r.Method + ":" + p["uri"] + ":" + h(string(body)))
+		want := h(ha1 + ":abc123:" + p["nc"] + ":" + p["cnonce"] + ":auth-int:" + ha2)
+		if p["qop"] != "auth-int" || p["uri"] != r.URL.RequestURI() || p["opaque"] != "xyz" || p["response"] != want {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		fmt.Fprintf(w, "ok %s", body)
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: &digestTransport{base: http.DefaultTransport, username: "user", password: "pass"}}
+	resp, err := client.Post(srv.URL+"/items?id=1", "application/json", strings.NewReader(`{"a":1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != `ok {"a":1}` {
+		t.Errorf("response = %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestSignHMACKnownAnswers(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+
+	// 默认规范串: "{method}\n{path}\n{query}\n{timestamp}\n{body_sha256}"，hex 编码
+	r, _ := http.NewRequest("POST", "https://api.example.com/v1/orders?b=2&a=1&q=x%20y&a=0", strings.NewReader(`{"item":"book","qty":2}`))
+	if err := signHMAC(r, map[string]string{"key_id": "key1", "secret": "s3cr3t"}, now); err != nil {
+		t.Fatalf("signHMAC: %v", err)
+	}
+	if got := r.Header.Get("X-Timestamp"); got != "1700000000" {
+		t.Errorf("X-Timestamp = %q", got)
+	}
+	want := "HMAC key1:b5be90412c78f4f2cb09700324c051a4aea7f1573a3a55a559bee9c5c98b215a"
+	if got := r.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+	// 签名后请求体仍可读取
+	if body, _ := io.ReadAll(r.Body); string(body) != `{"item":"book","qty":2}` {
+		t.Errorf("body after signing = %q", body)
+	}
+
+	// 自定义规范串、请求头、算法与编码
+	r, _ = http.NewRequest("GET", "https://api.example.com/v2/items", nil)
+	r.Header.Set("X-Api-Version", "2")
+	cfg := map[string]string{
+		"key_id":           "key1",
+		"secret":           "czNjcjN0", // base64("s3cr3t")
+		"secret_encoding":  "base64",
+		"algorithm":        "sha1",
+		"encoding":         "base64",
+		"canonical":        "{method}\n{host}\n{timestamp}\n{headers}",
+		"signed_headers":   "Host, X-Api-Version",
+		"timestamp_format": "rfc3339",
+		"timestamp_header": "X-Date",
+		"header":           "X-Signature",
+		"header_format":    "keyId={key_id},signature={signature}",
+	}
+	if err := signHMAC(r, cfg, now); err != nil {
+		t.Fatalf("signHMAC: %v", err)
+	}
+	if got := r.Header.Get("X-Date"); got != "2023-11-14T22:13:20Z" {
+		t.Errorf("X-Date = %q", got)
+	}
+	if got, want := r.Header.Get("X-Signature"), "keyId=key1,signature=Y0DuP1ngpU4Euwyo699wQh/+vH0="; got != want {
+		t.Errorf("X-Signature = %q, want %q", got, want)
+	}
+	if r.Header.Get("Authorization") != "" {
+		t.Error("Authorization should not be set when header is overridden")
+	}
+}
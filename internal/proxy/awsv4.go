@@ -0,0 +1,201 @@
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ---------------------------------------------------------------------------
+// AWS Signature Version 4
+// ---------------------------------------------------------------------------
+
+const (
+	awsV4Algorithm     = "AWS4-HMAC-SHA256"
+	awsV4TimeFormat    = "20060102T150405Z"
+	awsUnsignedPayload = "UNSIGNED-PAYLOAD"
+)
+
+// signAWSV4 按 SigV4 规范签名请求并写入 Authorization 请求头
+//
+// 配置项:
+//   - access_key, secret_key: 必填
+//   - session_token: 临时凭证 (STS) 的会话令牌，写入 X-Amz-Security-Token 并参与签名
+//   - region, service: 必填，例如 us-east-1 / execute-api、s3
+//   - unsigned_payload: 为 true 时不对请求体做摘要 (仅 S3 支持)
+//
+// 参与签名的请求头: host、content-type 与所有 x-amz-* 请求头
+// service 为 s3 时路径只编码一次且不做规范化，并总是携带 X-Amz-Content-Sha256
+func signAWSV4(r *http.Request, cfg map[string]string, now time.Time) error {
+	accessKey, secretKey := cfg["access_key"], cfg["secret_key"]
+	region, service := cfg["region"], strings.ToLower(cfg["service"])
+	if accessKey == "" || secretKey == "" {
+		return fmt.Errorf("AWS Signature 缺少 access_key 或 secret_key")
+	}
+	if region == "" || service == "" {
+		return fmt.Errorf("AWS Signature 缺少 region 或 service")
+	}
+
+	amzDate := now.UTC().Format(awsV4TimeFormat)
+	r.Header.Set("X-Amz-Date", amzDate)
+	if token := cfg["session_token"]; token != "" {
+		r.Header.Set("X-Amz-Security-Token", token)
+	}
+
+	payloadHash := awsUnsignedPayload
+	if cfg["unsigned_payload"] != "true" {
+		body, err := readBody(r)
+		if err != nil {
+			return fmt.Errorf("AWS Signature 读取请求体失败: %v", err)
+		}
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	if service == "s3" || payloadHash == awsUnsignedPayload {
+		r.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+
+	// 按签名使用的编码发送路径，否则 url 包会原样发送 ! $ ' ( ) * + , ; = : @ 等字符，服务端计算的签名不一致
+	if r.URL.Opaque == "" && r.URL.Path != "" {
+		r.URL.RawPath = awsEncodedPath(r.URL)
+	}
+
+	signedHeaders, canonicalHdrs := awsCanonicalHeaders(r)
+	canonicalRequest := strings.Join([]string{
+		r.Method,
+		awsCanonicalURI(r.URL, service == "s3"),
+		awsCanonicalQuery(r.URL.Query()),
+		canonicalHdrs,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	date := amzDate[:8]
+	scope := date + "/" + region + "/" + service + "/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := awsV4Algorithm + "\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	r.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		awsV4Algorithm, accessKey, scope, signedHeaders, signature))
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// awsCanonicalURI 对每个路径段做 URI 编码；非 S3 服务先去除 . 与 .. 段并再编码一次
+func awsCanonicalURI(u *url.URL, s3 bool) string {
+	if u.EscapedPath() == "" {
+		return "/"
+	}
+	p := awsEncodedPath(u)
+	if s3 {
+		return p
+	}
+
+	cleaned := path.Clean(p)
+	if strings.HasSuffix(p, "/") && cleaned != "/" {
+		cleaned += "/"
+	}
+	segments := strings.Split(cleaned, "/")
+	for i, s := range segments {
+		segments[i] = awsURIEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsEncodedPath 按实际发送的路径段 (保留 %2F 等编码) 逐段做一次 URI 编码
+func awsEncodedPath(u *url.URL) string {
+	segments := strings.Split(u.EscapedPath(), "/")
+	for i, s := range segments {
+		if decoded, err := url.PathUnescape(s); err == nil {
+			s = decoded
+		}
+		segments[i] = awsURIEncode(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// awsCanonicalQuery 编码后按键、值排序
+func awsCanonicalQuery(q url.Values) string {
+	type pair struct{ k, v string }
+	var pairs []pair
+	for k, vals := range q {
+		for _, v := range vals {
+			pairs = append(pairs, pair{awsURIEncode(k), awsURIEncode(v)})
+		}
+	}
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].k != pairs[j].k {
+			return pairs[i].k < pairs[j].k
+		}
+		return pairs[i].v < pairs[j].v
+	})
+
+	parts := make([]string, len(pairs))
+	for i, p := range pairs {
+		parts[i] = p.k + "=" + p.v
+	}
+	return strings.Join(parts, "&")
+}
+
+// awsCanonicalHeaders 返回 SignedHeaders 与规范化请求头 (每行以换行结尾)
+func awsCanonicalHeaders(r *http.Request) (string, string) {
+	headers := map[string]string{"host": r.URL.Host}
+	if r.Host != "" {
+		headers["host"] = r.Host
+	}
+	for name, vals := range r.Header {
+		lower := strings.ToLower(name)
+		if lower != "content-type" && !strings.HasPrefix(lower, "x-amz-") {
+			continue
+		}
+		trimmed := make([]string, len(vals))
+		for i, v := range vals {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[lower] = strings.Join(trimmed, ",")
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name + ":" + headers[name] + "\n")
+	}
+	return strings.Join(names, ";"), b.String()
+}
+
+// awsURIEncode 除非保留字符 (A-Z a-z 0-9 - _ . ~) 外全部以 %XX (大写) 编码
+func awsURIEncode(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if 'A' <= c && c <= 'Z' || 'a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-' || c == '_' || c == '.' || c == '~' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
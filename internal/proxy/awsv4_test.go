@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 取自 AWS SigV4 测试套件 (aws-sig-v4-test-suite)
+func TestSignAWSV4TestSuite(t *testing.T) {
+	cfg := map[string]string{
+		"access_key": "AKIDEXAMPLE",
+		"secret_key": "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		"region":     "us-east-1",
+		"service":    "service",
+	}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	tests := []struct {
+		name          string
+		method, url   string
+		contentType   string
+		body          string
+		signedHeaders string
+		signature     string
+	}{
+		{
+			name: "get-vanilla", method: "GET", url: "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name: "get-vanilla-query-order-key-case", method: "GET", url: "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			signedHeaders: "host;x-amz-date",
+			signature:     "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name: "post-vanilla", method: "POST", url: "https://example.amazonaws.com/",
+			signedHeaders: "host;x-amz-date",
+			signature:     "5da7c1a2acd57cee7505fc6676e4e544621c30862966e37dddb68e92efbe5d6b",
+		},
+		{
+			name: "post-x-www-form-urlencoded", method: "POST", url: "https://example.amazonaws.com/",
+			contentType: "application/x-www-form-urlencoded", body: "Param1=value1",
+			signedHeaders: "content-type;host;x-amz-date",
+			signature:     "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.contentType != "" {
+				r.Header.Set("Content-Type", tt.contentType)
+			}
+			if err := signAWSV4(r, cfg, now); err != nil {
+				t.Fatalf("signAWSV4: %v", err)
+			}
+			if got := r.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+				t.Errorf("X-Amz-Date = %q", got)
+			}
+			want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=" +
+				tt.signedHeaders + ", Signature=" + tt.signature
+			if got := r.Header.Get("Authorization"); got != want {
+				t.Errorf("Authorization =\n  %s\nwant\n  %s", got, want)
+			}
+		})
+	}
+}
+
+func TestAWSCanonicalURI(t *testing.T) {
+	tests := []struct {
+		url  string
+		s3   bool
+		want string
+	}{
+		{"https://example.amazonaws.com", false, "/"},
+		{"https://example.amazonaws.com/example/../a/./b/", false, "/a/b/"},
+		{"https://example.amazonaws.com/a b", false, "/a%2520b"},
+		{"https://example.amazonaws.com/a b", true, "/a%20b"},
+		{"https://example.amazonaws.com/bucket//key/../x", true, "/bucket//key/../x"},
+		{"https://example.amazonaws.com/a%2Fb/c", true, "/a%2Fb/c"},
+		{"https://example.amazonaws.com/a%2Fb/c", false, "/a%252Fb/c"},
+	}
+	for _, tt := range tests {
+		r, _ := http.NewRequest("GET", tt.url, nil)
+		if got := awsCanonicalURI(r.URL, tt.s3); got != tt.want {
+			t.Errorf("awsCanonicalURI(%s, s3=%v) = %q, want %q", tt.url, tt.s3, got, tt.want)
+		}
+	}
+}
+
+func TestSignAWSV4S3UnsignedPayload(t *testing.T) {
+	r, _ := http.NewRequest("PUT", "https://bucket.s3.amazonaws.com/key", strings.NewReader("data"))
+	cfg := map[string]string{
+		"access_key": "AKIDEXAMPLE", "secret_key": "secret", "region": "us-east-1", "service": "s3",
+		"unsigned_payload": "true", "session_token": "token",
+	}
+	if err := signAWSV4(r, cfg, time.Now()); err != nil {
+		t.Fatalf("signAWSV4: %v", err)
+	}
+	if got := r.Header.Get("X-Amz-Content-Sha256"); got != "UNSIGNED-PAYLOAD" {
+		t.Errorf("X-Amz-Content-Sha256 = %q", got)
+	}
+	if got := r.Header.Get("Authorization"); !strings.Contains(got, "SignedHeaders=host;x-amz-content-sha256;x-amz-date;x-amz-security-token,") {
+		t.Errorf("Authorization = %q", got)
+	}
+}
+
+func TestSignAWSV4WirePath(t *testing.T) {
+	const key = "/bucket/photos/a!$'()*+,;=:@ b.jpg"
+	var wire string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		wire = r.RequestURI
+	}))
+	defer srv.Close()
+
+	r, _ := http.NewRequest("GET", srv.URL, nil)
+	r.URL.Path = key
+	cfg := map[string]string{"access_key": "AKIDEXAMPLE", "secret_key": "secret", "region": "us-east-1", "service": "s3"}
+	if err := signAWSV4(r, cfg, time.Now()); err != nil {
+		t.Fatalf("signAWSV4: %v", err)
+	}
+	const want = "/bucket/photos/a%21%24%27%28%29%2A%2B%2C%3B%3D%3A%40%20b.jpg"
+	if got := awsCanonicalURI(r.URL, true); got != want {
+		t.Errorf("canonical URI = %s, want %s", got, want)
+	}
+	resp, err := http.DefaultClient.Do(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if wire != want {
+		t.Errorf("wire path = %s, want %s", wire, want)
+	}
+}
@@ -0,0 +1,165 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"go-api-tester/internal/database"
+	"net"
+	"net/url"
+	"strings"
+)
+
+// findCertificate 返回与请求地址匹配度最高的已启用证书，没有匹配时返回 nil
+// 匹配优先级: 精确主机 > 通配子域名 (后缀越长越优先) > *，同级时指定端口的优先
+func findCertificate(u *url.URL) (*database.Certificate, error) {
+	if database.DB == nil {
+		return nil, nil
+	}
+	list, err := database.GetAllCertificates()
+	if err != nil {
+		return nil, err
+	}
+
+	host := canonicalHost(u)
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+
+	var best *database.Certificate
+	bestScore := -1
+	for _, c := range list {
+		if !c.Enabled {
+			continue
+		}
+		if score := matchHostPattern(c.HostPattern, host, port); score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best, nil
+}
+
+// matchHostPattern 返回匹配分值，-1 表示不匹配
+func matchHostPattern(pattern, host, port string) int {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	if pattern == "" {
+		return -1
+	}
+
+	portBonus := 0
+	if h, p, err := net.SplitHostPort(pattern); err == nil {
+		if p != port {
+			return -1
+		}
+		pattern = h
+		portBonus = 1
+	}
+
+	var score int
+	switch {
+	case pattern == "*":
+		score = 0
+	case strings.HasPrefix(pattern, "*."):
+		if !strings.HasSuffix(host, pattern[1:]) {
+			return -1
+		}
+		score = len(pattern)
+	case pattern == host:
+		score = 1 << 16
+	default:
+		return -1
+	}
+	return score*2 + portBonus
+}
+
+// buildTLSConfig 根据设置与证书构建 tls.Config，无需自定义时返回 nil (使用默认配置)
+func buildTLSConfig(skipVerify bool, cert *database.Certificate) (*tls.Config, error) {
+	if !skipVerify && cert == nil {
+		return nil, nil
+	}
+	cfg := &tls.Config{InsecureSkipVerify: skipVerify}
+	if cert == nil {
+		return cfg, nil
+	}
+
+	if cert.ClientCert != "" || cert.ClientKey != "" {
+		keyPEM, err := decryptKey(cert.ClientKey, cert.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		pair, err := tls.X509KeyPair([]byte(cert.ClientCert), keyPEM)
+		if err != nil {
+			return nil, fmt.Errorf("客户端证书或私钥无效: %v", err)
+		}
+		cfg.Certificates = []tls.Certificate{pair}
+	}
+
+	if strings.TrimSpace(cert.CACert) != "" {
+		// 在系统根证书基础上追加，避免同一主机模式下的公网地址校验失败
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(cert.CACert)) {
+			return nil, fmt.Errorf("CA 证书中没有有效的 PEM 证书")
+		}
+		cfg.RootCAs = pool
+	}
+	return cfg, nil
+}
+
+// decryptKey 使用口令解密传统 PEM 加密 (Proc-Type: 4,ENCRYPTED) 的私钥
+func decryptKey(keyPEM, passphrase string) ([]byte, error) {
+	block, _ := pem.Decode([]byte(keyPEM))
+	if block == nil {
+		return nil, fmt.Errorf("私钥不是有效的 PEM 格式")
+	}
+	if block.Type == "ENCRYPTED PRIVATE KEY" {
+		return nil, fmt.Errorf("不支持加密的 PKCS#8 私钥，请先转换为未加密格式或传统 PEM 加密格式")
+	}
+	//lint:ignore SA1019 传统 PEM 加密仍被 openssl 广泛使用
+	if !x509.IsEncryptedPEMBlock(block) {
+		return []byte(keyPEM), nil
+	}
+	if passphrase == "" {
+		return nil, fmt.Errorf("私钥已加密，需要提供口令")
+	}
+	//lint:ignore SA1019 同上
+	der, err := x509.DecryptPEMBlock(block, []byte(passphrase))
+	if err != nil {
+		return nil, fmt.Errorf("私钥解密失败: %v", err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: block.Type, Bytes: der}), nil
+}
+
+// ValidateCertificate 检查证书配置能否正常加载 (保存前校验)
+func ValidateCertificate(cert *database.Certificate) error {
+	if cert.ClientCert == "" && cert.ClientKey == "" && strings.TrimSpace(cert.CACert) == "" {
+		return fmt.Errorf("客户端证书与 CA 证书至少需要配置一项")
+	}
+	if (cert.ClientCert == "") != (cert.ClientKey == "") {
+		return fmt.Errorf("客户端证书与私钥需要同时配置")
+	}
+	_, err := buildTLSConfig(false, cert)
+	return err
+}
+
+// certificateFingerprint 证书内容摘要，证书被修改后对应的 Transport 随之更换
+func certificateFingerprint(cert *database.Certificate) string {
+	if cert == nil {
+		return ""
+	}
+	h := sha256.New()
+	for _, s := range []string{cert.ClientCert, cert.ClientKey, cert.Passphrase, cert.CACert} {
+		h.Write([]byte(s))
+		h.Write([]byte{0})
+	}
+	return fmt.Sprintf("%d:%s", cert.ID, hex.EncodeToString(h.Sum(nil)[:8]))
+}
@@ -0,0 +1,239 @@
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"go-api-tester/internal/database"
+	"io"
+	"log"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestMatchHostPattern(t *testing.T) {
+	tests := []struct {
+		pattern, host, port string
+		match               bool
+	}{
+		{"*", "any.host", "443", true},
+		{"api.example.com", "api.example.com", "443", true},
+		{"API.Example.com", "api.example.com", "443", true},
+		{"api.example.com", "www.example.com", "443", false},
+		{"*.example.com", "api.example.com", "443", true},
+		{"*.example.com", "a.b.example.com", "443", true},
+		{"*.example.com", "example.com", "443", false},
+		{"*.example.com", "badexample.com", "443", false},
+		{"api.example.com:8443", "api.example.com", "8443", true},
+		{"api.example.com:8443", "api.example.com", "443", false},
+		{"*:8443", "any.host", "8443", true},
+		{"", "api.example.com", "443", false},
+	}
+	for _, tt := range tests {
+		if got := matchHostPattern(tt.pattern, tt.host, tt.port) >= 0; got != tt.match {
+			t.Errorf("matchHostPattern(%q, %q, %q) = %v, want %v", tt.pattern, tt.host, tt.port, got, tt.match)
+		}
+	}
+}
+
+func TestFindCertificatePriority(t *testing.T) {
+	openTestDB(t)
+	for _, c := range []database.Certificate{
+		{Name: "any", HostPattern: "*", Enabled: true},
+		{Name: "wildcard", HostPattern: "*.example.com", Enabled: true},
+		{Name: "deep wildcard", HostPattern: "*.api.example.com", Enabled: true},
+		{Name: "exact", HostPattern: "api.example.com", Enabled: true},
+		{Name: "exact with port", HostPattern: "api.example.com:8443", Enabled: true},
+		{Name: "disabled", HostPattern: "www.example.com", Enabled: false},
+	} {
+		if _, err := database.CreateCertificate(&c); err != nil {
+			t.Fatalf("create certificate: %v", err)
+		}
+	}
+
+	tests := []struct {
+		url, want string
+	}{
+		{"https://api.example.com/", "exact"},
+		{"https://API.example.com:443/", "exact"},
+		{"https://api.example.com:8443/", "exact with port"},
+		{"https://v1.api.example.com/", "deep wildcard"},
+		{"https://www.example.com/", "wildcard"},
+		{"https://other.org/", "any"},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.url)
+		c, err := findCertificate(u)
+		if err != nil {
+			t.Fatalf("findCertificate(%s): %v", tt.url, err)
+		}
+		if c == nil || c.Name != tt.want {
+			t.Errorf("findCertificate(%s) = %+v, want %q", tt.url, c, tt.want)
+		}
+	}
+}
+
+// testCA 测试用 CA，签发服务端与客户端证书
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  string
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, _ := x509.ParseCertificate(der)
+	return &testCA{cert: cert, key: key, pem: string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))}
+}
+
+// issue 签发证书，返回 PEM 格式的证书与私钥
+func (ca *testCA) issue(t *testing.T, cn string, usage x509.ExtKeyUsage, ips ...net.IP) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+		IPAddresses:  ips,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestClientCertificateMTLS(t *testing.T) {
+	openTestDB(t)
+	ca := newTestCA(t)
+	serverCert, serverKey := ca.issue(t, "server", x509.ExtKeyUsageServerAuth, net.ParseIP("127.0.0.1"))
+	clientCert, clientKey := ca.issue(t, "test-client", x509.ExtKeyUsageClientAuth)
+
+	pair, err := tls.X509KeyPair([]byte(serverCert), []byte(serverKey))
+	if err != nil {
+		t.Fatal(err)
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0) // 握手失败是预期的
+	srv.TLS = &tls.Config{
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+	port := srv.Listener.Addr().(*net.TCPAddr).Port
+
+	// 没有匹配的证书: 服务端证书不受信任，握手失败
+	if resp := doRequest(ProxyRequest{Method: "GET", URL: srv.URL}); resp.Error == "" {
+		t.Fatalf("expected TLS error without certificate, got %d", resp.StatusCode)
+	}
+
+	c := &database.Certificate{Name: "other host", HostPattern: "*.example.com", ClientCert: clientCert, ClientKey: clientKey, CACert: ca.pem, Enabled: true}
+	if _, err := database.CreateCertificate(c); err != nil {
+		t.Fatal(err)
+	}
+	if resp := doRequest(ProxyRequest{Method: "GET", URL: srv.URL}); resp.Error == "" {
+		t.Fatalf("certificate for other host should not be used, got %d", resp.StatusCode)
+	}
+
+	// 按 IP:端口 匹配后使用客户端证书与 CA 完成双向认证
+	c = &database.Certificate{Name: "local", HostPattern: net.JoinHostPort("127.0.0.1", strconv.Itoa(port)), ClientCert: clientCert, ClientKey: clientKey, CACert: ca.pem, Enabled: true}
+	if _, err := database.CreateCertificate(c); err != nil {
+		t.Fatal(err)
+	}
+	resp := doRequest(ProxyRequest{Method: "GET", URL: srv.URL})
+	if resp.Error != "" {
+		t.Fatalf("mTLS request failed: %s", resp.Error)
+	}
+	if resp.StatusCode != http.StatusOK || resp.Body != "test-client" {
+		t.Errorf("response = %d %q, want 200 %q", resp.StatusCode, resp.Body, "test-client")
+	}
+}
+
+func TestCACertificateOnly(t *testing.T) {
+	openTestDB(t)
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	if resp := doRequest(ProxyRequest{Method: "GET", URL: srv.URL}); resp.Error == "" {
+		t.Fatal("expected error for untrusted server certificate")
+	}
+
+	caPEM := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw}))
+	if _, err := database.CreateCertificate(&database.Certificate{Name: "ca", HostPattern: "127.0.0.1", CACert: caPEM, Enabled: true}); err != nil {
+		t.Fatal(err)
+	}
+	resp := doRequest(ProxyRequest{Method: "GET", URL: srv.URL})
+	if resp.Error != "" || resp.Body != "ok" {
+		t.Fatalf("response = %q, error = %q", resp.Body, resp.Error)
+	}
+}
+
+func TestValidateCertificate(t *testing.T) {
+	ca := newTestCA(t)
+	cert, key := ca.issue(t, "client", x509.ExtKeyUsageClientAuth)
+	tests := []struct {
+		name string
+		cert database.Certificate
+		ok   bool
+	}{
+		{"client pair", database.Certificate{ClientCert: cert, ClientKey: key}, true},
+		{"ca only", database.Certificate{CACert: ca.pem}, true},
+		{"empty", database.Certificate{}, false},
+		{"cert without key", database.Certificate{ClientCert: cert}, false},
+		{"mismatched key", database.Certificate{ClientCert: ca.pem, ClientKey: key}, false},
+		{"invalid ca", database.Certificate{CACert: "not a pem"}, false},
+	}
+	for _, tt := range tests {
+		if err := ValidateCertificate(&tt.cert); (err == nil) != tt.ok {
+			t.Errorf("%s: ValidateCertificate() = %v, want ok=%v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package proxy
+
+import (
+	"fmt"
+	"go-api-tester/internal/database"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// 内置默认值 (全局设置与请求设置均未指定时使用)
+const (
+	defaultTimeoutMs    = 60 * 1000
+	defaultMaxRedirects = 10
+)
+
+// effectiveSettings 合并 内置默认值 -> 全局设置 -> 请求设置
+func effectiveSettings(req ProxyRequest) (database.ClientSettings, error) {
+	follow := true
+	settings := database.ClientSettings{
+		TimeoutMs:       defaultTimeoutMs,
+		FollowRedirects: &follow,
+		MaxRedirects:    defaultMaxRedirects,
+	}
+	if database.DB != nil {
+		global, err := database.GetClientSettings()
+		if err != nil {
+			return settings, err
+		}
+		settings = settings.Merge(global)
+	}
+	return settings.Merge(req.Settings), nil
+}
+
+// redirectRecorder 记录实际发生的重定向
+type redirectRecorder struct {
+	hops []RedirectHop
+}
+
+// buildClient 根据设置、目标地址匹配的证书与上游代理构建 http.Client
+// 证书按初始请求地址匹配，重定向到其他主机时沿用同一 TLS 配置
+func buildClient(req ProxyRequest, target *url.URL, settings database.ClientSettings, recorder *redirectRecorder) (*http.Client, error) {
+	cert, err := findCertificate(target)
+	if err != nil {
+		return nil, fmt.Errorf("加载证书失败: %v", err)
+	}
+	proxy, err := resolveProxySettings(req)
+	if err != nil {
+		return nil, fmt.Errorf("加载代理配置失败: %v", err)
+	}
+	transport, err := getTransport(settings, cert, proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &http.Client{
+		Transport: transport,
+		Timeout:   time.Duration(settings.TimeoutMs) * time.Millisecond,
+	}
+	if req.Auth.Type == AuthDigest {
+		client.Transport = &digestTransport{base: transport, username: req.Auth.Digest["username"], password: req.Auth.Digest["password"]}
+	}
+	if jar := newCookieJar(req); jar != nil {
+		client.Jar = jar
+	}
+
+	follow := settings.FollowRedirects == nil || *settings.FollowRedirects
+	maxRedirects := settings.MaxRedirects
+	client.CheckRedirect = func(next *http.Request, via []*http.Request) error {
+		if !follow {
+			// 不跟随: 直接返回 3xx 响应
+			return http.ErrUseLastResponse
+		}
+		if len(via) > maxRedirects {
+			return fmt.Errorf("超过最大重定向次数 (%d)", maxRedirects)
+		}
+		hop := RedirectHop{URL: via[len(via)-1].URL.String(), Location: next.URL.String()}
+		if next.Response != nil {
+			hop.Status = next.Response.StatusCode
+		}
+		recorder.hops = append(recorder.hops, hop)
+		return nil
+	}
+	return client, nil
+}
+
+// transportKey 决定 Transport 是否可以复用 (设置相同的请求共享连接池)
+type transportKey struct {
+	skipTLSVerify bool
+	httpVersion   string
+	certificate   string // 证书指纹，未使用证书时为空
+	proxy         string // 上游代理配置摘要
+}
+
+var (
+	transportMu    sync.Mutex
+	transportCache = make(map[transportKey]*http.Transport)
+)
+
+// getTransport 返回与设置匹配的 Transport，按设置缓存以保留连接复用
+func getTransport(settings database.ClientSettings, cert *database.Certificate, proxy database.ProxySettings) (*http.Transport, error) {
+	key := transportKey{
+		skipTLSVerify: settings.SkipTLSVerify != nil && *settings.SkipTLSVerify,
+		httpVersion:   settings.HTTPVersion,
+		certificate:   certificateFingerprint(cert),
+		proxy:         proxyFingerprint(proxy),
+	}
+
+	transportMu.Lock()
+	defer transportMu.Unlock()
+	if t, ok := transportCache[key]; ok {
+		return t, nil
+	}
+
+	tlsConfig, err := buildTLSConfig(key.skipTLSVerify, cert)
+	if err != nil {
+		return nil, err
+	}
+	proxyFn, err := proxyFunc(proxy)
+	if err != nil {
+		return nil, err
+	}
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.Proxy = proxyFn
+	if tlsConfig != nil {
+		t.TLSClientConfig = tlsConfig
+	}
+
+	switch key.httpVersion {
+	case "":
+	case "1.1":
+		var p http.Protocols
+		p.SetHTTP1(true)
+		t.Protocols = &p
+	case "2":
+		// HTTPS 通过 ALPN 协商 h2，HTTP 使用 h2c (prior knowledge)
+		var p http.Protocols
+		p.SetHTTP2(true)
+		p.SetUnencryptedHTTP2(true)
+		t.Protocols = &p
+	default:
+		return nil, fmt.Errorf("不支持的 HTTP 版本: %s", key.httpVersion)
+	}
+
+	transportCache[key] = t
+	return t, nil
+}
@@ -0,0 +1,161 @@
+package proxy
+
+import (
+	"go-api-tester/internal/database"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// dbCookieJar 基于 SQLite 持久化的 CookieJar，按环境隔离
+// 实现 http.CookieJar，重定向过程中的 Set-Cookie 也会被记录
+type dbCookieJar struct {
+	envID int64
+}
+
+// newCookieJar 返回请求使用的 CookieJar，禁用或数据库不可用时返回 nil
+func newCookieJar(req ProxyRequest) http.CookieJar {
+	if req.DisableCookies || database.DB == nil {
+		return nil
+	}
+	var envID int64
+	if env, err := loadEnvironment(req.EnvironmentID); err == nil && env != nil {
+		envID = env.ID
+	}
+	return &dbCookieJar{envID: envID}
+}
+
+// SetCookies 保存响应中的 Cookie，Max-Age<0 或已过期的 Cookie 会被删除
+// 未设置 Expires / Max-Age 的会话 Cookie 在程序重新启动时清除
+func (j *dbCookieJar) SetCookies(u *url.URL, cookies []*http.Cookie) {
+	host := canonicalHost(u)
+	now := time.Now()
+
+	for _, hc := range cookies {
+		c := &database.Cookie{
+			EnvironmentID: j.envID,
+			Domain:        host,
+			Path:          hc.Path,
+			Name:          hc.Name,
+			Value:         hc.Value,
+			Secure:        hc.Secure,
+			HttpOnly:      hc.HttpOnly,
+			HostOnly:      true,
+		}
+
+		// Domain 属性: 只接受当前主机或其父域名
+		// 单标签域名 (如 com、localhost) 与 IP 地址只能设置为当前主机本身，按 HostOnly 保存
+		if hc.Domain != "" {
+			domain := strings.ToLower(strings.TrimPrefix(hc.Domain, "."))
+			if host != domain && !strings.HasSuffix(host, "."+domain) {
+				continue
+			}
+			if !strings.Contains(domain, ".") || net.ParseIP(host) != nil {
+				if host != domain {
+					continue
+				}
+			} else {
+				c.Domain = domain
+				c.HostOnly = false
+			}
+		}
+
+		// Path 属性缺省时取请求路径的目录部分
+		if c.Path == "" || c.Path[0] != '/' {
+			c.Path = defaultCookiePath(u.Path)
+		}
+
+		// 过期处理: Max-Age 优先于 Expires
+		expired := false
+		switch {
+		case hc.MaxAge < 0:
+			expired = true
+		case hc.MaxAge > 0:
+			t := now.Add(time.Duration(hc.MaxAge) * time.Second)
+			c.Expires = &t
+		case !hc.Expires.IsZero():
+			t := hc.Expires
+			c.Expires = &t
+			expired = !t.After(now)
+		}
+
+		var err error
+		if expired {
+			err = database.DeleteCookieByKey(c.EnvironmentID, c.Domain, c.Path, c.Name)
+		} else {
+			_, err = database.SaveCookie(c)
+		}
+		if err != nil {
+			log.Printf("[COOKIE] save %s failed: %v", hc.Name, err)
+		}
+	}
+}
+
+// Cookies 返回应随请求发送的 Cookie
+func (j *dbCookieJar) Cookies(u *url.URL) []*http.Cookie {
+	list, err := database.GetCookies(&j.envID, "")
+	if err != nil {
+		log.Printf("[COOKIE] load failed: %v", err)
+		return nil
+	}
+
+	host := canonicalHost(u)
+	path := u.Path
+	if path == "" {
+		path = "/"
+	}
+	secure := u.Scheme == "https"
+	now := time.Now()
+
+	var out []*http.Cookie
+	for _, c := range list {
+		if c.Expired(now) || (c.Secure && !secure) {
+			continue
+		}
+		if !domainMatch(host, c.Domain, c.HostOnly) || !pathMatch(path, c.Path) {
+			continue
+		}
+		out = append(out, &http.Cookie{Name: c.Name, Value: c.Value})
+	}
+	return out
+}
+
+func canonicalHost(u *url.URL) string {
+	host := u.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(host)
+}
+
+func domainMatch(host, domain string, hostOnly bool) bool {
+	if host == domain {
+		return true
+	}
+	return !hostOnly && strings.HasSuffix(host, "."+domain)
+}
+
+func pathMatch(reqPath, cookiePath string) bool {
+	if reqPath == cookiePath {
+		return true
+	}
+	if strings.HasPrefix(reqPath, cookiePath) {
+		return strings.HasSuffix(cookiePath, "/") || reqPath[len(cookiePath)] == '/'
+	}
+	return false
+}
+
+// defaultCookiePath RFC 6265 5.1.4 默认路径
+func defaultCookiePath(p string) string {
+	if p == "" || p[0] != '/' {
+		return "/"
+	}
+	i := strings.LastIndex(p, "/")
+	if i == 0 {
+		return "/"
+	}
+	return p[:i]
+}
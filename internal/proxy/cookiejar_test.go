@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"go-api-tester/internal/database"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+func storedCookies(t *testing.T) []string {
+	t.Helper()
+	list, err := database.GetCookies(nil, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out []string
+	for _, c := range list {
+		s := c.Domain + " " + c.Name
+		if c.HostOnly {
+			s += " host-only"
+		}
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestCookieJarDomain(t *testing.T) {
+	openTestDB(t)
+	jar := &dbCookieJar{}
+	u, _ := url.Parse("https://api.example.com/v1/login")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "host", Value: "1", MaxAge: 60},
+		{Name: "parent", Value: "1", Domain: ".example.com", Path: "/", MaxAge: 60},
+		{Name: "tld", Value: "1", Domain: "com", MaxAge: 60},
+		{Name: "other", Value: "1", Domain: "other.com", MaxAge: 60},
+	})
+	local, _ := url.Parse("http://localhost:8080/")
+	jar.SetCookies(local, []*http.Cookie{{Name: "local", Value: "1", Domain: "localhost", MaxAge: 60}})
+	ip, _ := url.Parse("http://10.0.0.1/")
+	jar.SetCookies(ip, []*http.Cookie{
+		{Name: "ip", Value: "1", Domain: "10.0.0.1", MaxAge: 60},
+		{Name: "ip-parent", Value: "1", Domain: "0.0.1", MaxAge: 60},
+	})
+
+	want := []string{"10.0.0.1 ip host-only", "api.example.com host host-only", "example.com parent", "localhost local host-only"}
+	if got := storedCookies(t); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("cookies = %v, want %v", got, want)
+	}
+
+	sub, _ := url.Parse("https://www.example.com/")
+	if got := jar.Cookies(sub); len(got) != 1 || got[0].Name != "parent" {
+		t.Errorf("cookies for sibling host = %v", got)
+	}
+}
+
+func TestSessionCookiesClearedOnRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.db")
+	if err := database.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		database.DB = nil
+	})
+
+	jar := &dbCookieJar{}
+	u, _ := url.Parse("https://example.com/")
+	jar.SetCookies(u, []*http.Cookie{
+		{Name: "session", Value: "1"},
+		{Name: "max-age", Value: "1", MaxAge: 3600},
+		{Name: "expires", Value: "1", Expires: time.Now().Add(time.Hour)},
+	})
+	if got := storedCookies(t); len(got) != 3 {
+		t.Fatalf("cookies before restart = %v", got)
+	}
+
+	database.Close()
+	if err := database.Open(path); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"example.com expires host-only", "example.com max-age host-only"}
+	if got := storedCookies(t); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("cookies after restart = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+package proxy
+
+import (
+	"fmt"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/jsonpath"
+	"net/http"
+	"regexp"
+)
+
+// runExtractors 执行启用的提取器并将结果写入对应作用域
+func runExtractors(req ProxyRequest, resp ProxyResponse) []ExtractResult {
+	var results []ExtractResult
+	for _, ex := range req.Extractors {
+		if !ex.Enabled || ex.Variable == "" {
+			continue
+		}
+		result := ExtractResult{Variable: ex.Variable, Scope: database.ScopeRuntime}
+
+		value, err := extractValue(ex, resp)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Value = value
+
+		if ex.Scope == database.ScopeEnvironment {
+			if err := saveToEnvironment(req.EnvironmentID, ex.Variable, value); err != nil {
+				// 写入环境失败时退回运行时作用域，保证后续请求仍可使用
+				result.Error = "写入环境失败，已保存为运行时变量: " + err.Error()
+			} else {
+				result.Scope = database.ScopeEnvironment
+			}
+		}
+		// 运行时作用域始终更新，使本次运行中的后续请求立即可见
+		req.runtime().Set(ex.Variable, value)
+
+		results = append(results, result)
+	}
+	return results
+}
+
+// extractValue 按提取器类型从响应中取值
+func extractValue(ex database.Extractor, resp ProxyResponse) (string, error) {
+	if resp.Error != "" {
+		return "", fmt.Errorf("请求失败: %s", resp.Error)
+	}
+
+	switch ex.Type {
+	case database.ExtractJSONPath:
+		if resp.IsBinary {
+			return "", fmt.Errorf("响应为二进制内容，无法解析 JSON")
+		}
+		val, err := jsonpath.Lookup([]byte(resp.Body), ex.Expression)
+		if err != nil {
+			return "", err
+		}
+		return jsonpath.Stringify(val), nil
+
+	case database.ExtractRegex:
+		if resp.IsBinary {
+			return "", fmt.Errorf("响应为二进制内容，无法使用正则提取")
+		}
+		re, err := regexp.Compile(ex.Expression)
+		if err != nil {
+			return "", fmt.Errorf("无效的正则表达式: %v", err)
+		}
+		m := re.FindStringSubmatch(resp.Body)
+		if m == nil {
+			return "", fmt.Errorf("正则 /%s/ 未匹配到内容", ex.Expression)
+		}
+		if len(m) > 1 {
+			return m[1], nil
+		}
+		return m[0], nil
+
+	case database.ExtractHeader:
+		name := http.CanonicalHeaderKey(ex.Expression)
+		values := resp.Headers[name]
+		if len(values) == 0 {
+			return "", fmt.Errorf("响应头 %s 不存在", name)
+		}
+		return values[0], nil
+
+	case database.ExtractCookie:
+		// 借助 http.Response 解析 Set-Cookie
+		cookies := (&http.Response{Header: resp.Headers}).Cookies()
+		for _, c := range cookies {
+			if c.Name == ex.Expression {
+				return c.Value, nil
+			}
+		}
+		return "", fmt.Errorf("Cookie %s 不存在", ex.Expression)
+	}
+	return "", fmt.Errorf("未知的提取器类型: %s", ex.Type)
+}
+
+// saveToEnvironment 将变量写回指定环境 (0 表示当前激活的环境)
+func saveToEnvironment(envID int64, key, value string) error {
+	if database.DB == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+	env, err := loadEnvironment(envID)
+	if err != nil {
+		return err
+	}
+	if env == nil {
+		return fmt.Errorf("没有激活的环境")
+	}
+	return database.SetEnvironmentVariable(env.ID, key, value)
+}
@@ -0,0 +1,36 @@
+package proxy
+
+import (
+	"go-api-tester/internal/database"
+	"testing"
+)
+
+func TestExtractValue(t *testing.T) {
+	resp := ProxyResponse{
+		StatusCode: 200,
+		Headers:    map[string][]string{"X-Request-Id": {"req-1"}},
+		Body:       `{"id": 9007199254740993, "total": 1e21, "token": "abc"}`,
+	}
+	tests := []struct {
+		ex   database.Extractor
+		want string
+	}{
+		{database.Extractor{Type: database.ExtractJSONPath, Expression: "$.id"}, "9007199254740993"},
+		{database.Extractor{Type: database.ExtractJSONPath, Expression: "$.total"}, "1e21"},
+		{database.Extractor{Type: database.ExtractRegex, Expression: `"token": "(\w+)"`}, "abc"},
+		{database.Extractor{Type: database.ExtractHeader, Expression: "x-request-id"}, "req-1"},
+	}
+	for _, tt := range tests {
+		got, err := extractValue(tt.ex, resp)
+		if err != nil || got != tt.want {
+			t.Errorf("extractValue(%s %s) = %q, %v, want %q", tt.ex.Type, tt.ex.Expression, got, err, tt.want)
+		}
+	}
+
+	binary := ProxyResponse{StatusCode: 200, Body: "AAEC", IsBinary: true}
+	for _, typ := range []string{database.ExtractJSONPath, database.ExtractRegex} {
+		if _, err := extractValue(database.Extractor{Type: typ, Expression: "."}, binary); err == nil {
+			t.Errorf("%s on binary response: expected error", typ)
+		}
+	}
+}
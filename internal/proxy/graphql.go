@@ -0,0 +1,243 @@
+package proxy
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/graphql"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SchemaInfo GraphQL schema 缓存信息
+type SchemaInfo struct {
+	URL        string             `json:"url"`
+	FetchedAt  time.Time          `json:"fetched_at"`
+	Cached     bool               `json:"cached"` // true 表示直接返回了缓存，未重新内省
+	Operations graphql.Operations `json:"operations"`
+	SDL        string             `json:"sdl"`
+}
+
+// GraphQLValidation 查询校验结果
+type GraphQLValidation struct {
+	URL          string                      `json:"url"`
+	SchemaCached bool                        `json:"schema_cached"` // false 时只做了语法检查
+	Valid        bool                        `json:"valid"`
+	Errors       []graphql.QueryError        `json:"errors,omitempty"`
+	Operations   []graphql.DocumentOperation `json:"operations"` // 查询文档中定义的操作
+}
+
+// graphqlBody 编码 GraphQL 请求体 {query, variables, operationName}
+func graphqlBody(req ProxyRequest) ([]byte, error) {
+	payload := map[string]interface{}{"query": req.GraphQLQuery}
+	if vars := strings.TrimSpace(req.GraphQLVars); vars != "" {
+		var v map[string]interface{}
+		if err := json.Unmarshal([]byte(vars), &v); err != nil {
+			return nil, fmt.Errorf("variables 必须是 JSON 对象: %v", err)
+		}
+		payload["variables"] = v
+	}
+	if req.GraphQLOperation != "" {
+		payload["operationName"] = req.GraphQLOperation
+	}
+	return json.Marshal(payload)
+}
+
+// graphqlEndpoint 返回 schema 缓存使用的地址 (补全协议，去掉查询参数与片段)
+func graphqlEndpoint(rawURL string) string {
+	if !strings.HasPrefix(rawURL, "http://") && !strings.HasPrefix(rawURL, "https://") {
+		rawURL = "http://" + rawURL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return rawURL
+	}
+	u.RawQuery, u.Fragment = "", ""
+	return u.String()
+}
+
+// resolveRequest 解析继承的认证并替换变量，存在未解析变量时返回错误
+func resolveRequest(req *ProxyRequest) error {
+	if _, err := resolveInheritedAuth(req); err != nil {
+		return err
+	}
+	missing, err := substituteVariables(req)
+	if err != nil {
+		return err
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("存在未解析的变量: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// IntrospectSchema 对请求地址执行内省并缓存 schema，refresh 为 false 且已有缓存时直接返回缓存
+// 内省请求沿用 req 的请求头、认证、证书与代理配置
+func IntrospectSchema(req ProxyRequest, refresh bool) (*SchemaInfo, error) {
+	if err := resolveRequest(&req); err != nil {
+		return nil, err
+	}
+	endpoint := graphqlEndpoint(req.URL)
+
+	if !refresh {
+		cached, err := database.GetGraphQLSchema(endpoint)
+		if err == nil {
+			return schemaInfo(cached, true)
+		}
+		if err != sql.ErrNoRows {
+			return nil, err
+		}
+	}
+
+	introspect := req
+	introspect.Method = "POST"
+	introspect.BodyType = "graphql"
+	introspect.GraphQLQuery = graphql.IntrospectionQuery
+	introspect.GraphQLVars = ""
+	introspect.GraphQLOperation = "IntrospectionQuery"
+
+	resp := doRequest(introspect)
+	if resp.Error != "" {
+		return nil, fmt.Errorf("内省请求失败: %s", resp.Error)
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("内省请求返回 %d: %s", resp.StatusCode, truncate(resp.Body, 200))
+	}
+	if resp.IsBinary {
+		return nil, fmt.Errorf("内省响应不是文本")
+	}
+
+	schema, err := graphql.ParseIntrospection([]byte(resp.Body))
+	if err != nil {
+		return nil, err
+	}
+	sdl := schema.SDL()
+	if _, err := graphql.NewValidator(sdl); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+
+	cached := &database.GraphQLSchema{URL: endpoint, Introspection: string(raw), SDL: sdl, FetchedAt: time.Now().UTC()}
+	if err := database.SaveGraphQLSchema(cached); err != nil {
+		return nil, fmt.Errorf("保存 schema 失败: %v", err)
+	}
+	return schemaInfo(cached, false)
+}
+
+// CachedSchema 获取已缓存的 schema，未缓存时返回 sql.ErrNoRows
+func CachedSchema(rawURL string) (*SchemaInfo, error) {
+	cached, err := database.GetGraphQLSchema(graphqlEndpoint(rawURL))
+	if err != nil {
+		return nil, err
+	}
+	return schemaInfo(cached, true)
+}
+
+// ClearCachedSchema 删除已缓存的 schema
+func ClearCachedSchema(rawURL string) error {
+	endpoint := graphqlEndpoint(rawURL)
+	validatorMu.Lock()
+	delete(validatorCache, endpoint)
+	validatorMu.Unlock()
+	return database.DeleteGraphQLSchema(endpoint)
+}
+
+func schemaInfo(cached *database.GraphQLSchema, fromCache bool) (*SchemaInfo, error) {
+	var schema graphql.Schema
+	if err := json.Unmarshal([]byte(cached.Introspection), &schema); err != nil {
+		return nil, fmt.Errorf("schema 缓存已损坏: %v", err)
+	}
+	return &SchemaInfo{
+		URL:        cached.URL,
+		FetchedAt:  cached.FetchedAt,
+		Cached:     fromCache,
+		Operations: schema.Operations(),
+		SDL:        cached.SDL,
+	}, nil
+}
+
+// ValidateGraphQL 校验请求中的 GraphQL 查询
+// 已缓存 schema 时按 schema 完整校验，否则只做语法检查
+func ValidateGraphQL(req ProxyRequest) (*GraphQLValidation, error) {
+	if err := resolveRequest(&req); err != nil {
+		return nil, err
+	}
+	result := &GraphQLValidation{URL: graphqlEndpoint(req.URL)}
+
+	ops, errs := graphql.ParseOperations(req.GraphQLQuery)
+	result.Operations = ops
+	if len(errs) == 0 {
+		v, err := cachedValidator(result.URL)
+		if err != nil {
+			return nil, err
+		}
+		if v != nil {
+			result.SchemaCached = true
+			errs = v.Validate(req.GraphQLQuery, req.GraphQLOperation)
+		}
+	}
+	if result.Operations == nil {
+		result.Operations = []graphql.DocumentOperation{}
+	}
+	result.Errors = errs
+	result.Valid = len(errs) == 0
+	return result, nil
+}
+
+// validateBeforeSend 发送前按缓存的 schema 校验查询，未缓存 schema 时不校验
+func validateBeforeSend(req ProxyRequest) ([]graphql.QueryError, error) {
+	v, err := cachedValidator(graphqlEndpoint(req.URL))
+	if err != nil || v == nil {
+		return nil, err
+	}
+	return v.Validate(req.GraphQLQuery, req.GraphQLOperation), nil
+}
+
+// 已加载的校验器，按地址缓存，schema 重新内省后 (FetchedAt 变化) 自动重建
+type validatorEntry struct {
+	fetchedAt time.Time
+	validator *graphql.Validator
+}
+
+var (
+	validatorMu    sync.Mutex
+	validatorCache = make(map[string]validatorEntry)
+)
+
+func cachedValidator(endpoint string) (*graphql.Validator, error) {
+	if database.DB == nil {
+		return nil, nil
+	}
+	cached, err := database.GetGraphQLSchema(endpoint)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	validatorMu.Lock()
+	defer validatorMu.Unlock()
+	if e, ok := validatorCache[endpoint]; ok && e.fetchedAt.Equal(cached.FetchedAt) {
+		return e.validator, nil
+	}
+	v, err := graphql.NewValidator(cached.SDL)
+	if err != nil {
+		return nil, err
+	}
+	validatorCache[endpoint] = validatorEntry{fetchedAt: cached.FetchedAt, validator: v}
+	return v, nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
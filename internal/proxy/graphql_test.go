@@ -0,0 +1,127 @@
+package proxy
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+const testIntrospection = `{"data": {"__schema": {
+	"queryType": {"name": "Query"},
+	"types": [
+		{"kind": "OBJECT", "name": "Query", "fields": [
+			{"name": "user", "args": [{"name": "id", "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}}],
+			 "type": {"kind": "OBJECT", "name": "User"}}
+		]},
+		{"kind": "OBJECT", "name": "User", "fields": [
+			{"name": "id", "args": [], "type": {"kind": "NON_NULL", "ofType": {"kind": "SCALAR", "name": "ID"}}},
+			{"name": "name", "args": [], "type": {"kind": "SCALAR", "name": "String"}}
+		]},
+		{"kind": "SCALAR", "name": "ID"},
+		{"kind": "SCALAR", "name": "String"}
+	],
+	"directives": []
+}}}`
+
+func TestGraphQLBody(t *testing.T) {
+	body, err := graphqlBody(ProxyRequest{GraphQLQuery: "query Q { a }", GraphQLVars: `{"id": 1}`, GraphQLOperation: "Q"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != `{"operationName":"Q","query":"query Q { a }","variables":{"id":1}}` {
+		t.Errorf("body = %s", body)
+	}
+	if body, _ := graphqlBody(ProxyRequest{GraphQLQuery: "{ a }", GraphQLVars: "  "}); string(body) != `{"query":"{ a }"}` {
+		t.Errorf("body without variables = %s", body)
+	}
+	if _, err := graphqlBody(ProxyRequest{GraphQLQuery: "{ a }", GraphQLVars: "[1]"}); err == nil {
+		t.Error("expected error for non-object variables")
+	}
+}
+
+func TestGraphQLEndpoint(t *testing.T) {
+	tests := map[string]string{
+		"https://api.example.com/graphql?x=1#f": "https://api.example.com/graphql",
+		"api.example.com/graphql":               "http://api.example.com/graphql",
+	}
+	for in, want := range tests {
+		if got := graphqlEndpoint(in); got != want {
+			t.Errorf("graphqlEndpoint(%s) = %s, want %s", in, got, want)
+		}
+	}
+}
+
+func TestGraphQLIntrospectionAndValidation(t *testing.T) {
+	openTestDB(t)
+	var introspections atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Query         string `json:"query"`
+			OperationName string `json:"operationName"`
+		}
+		b, _ := io.ReadAll(r.Body)
+		if r.Header.Get("Content-Type") != "application/json" || json.Unmarshal(b, &payload) != nil {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		if payload.OperationName == "IntrospectionQuery" {
+			introspections.Add(1)
+			w.Write([]byte(testIntrospection))
+			return
+		}
+		w.Write([]byte(`{"data": {"user": {"id": "1"}}}`))
+	}))
+	defer srv.Close()
+	url := srv.URL + "/graphql"
+
+	// 未缓存 schema 时只做语法检查
+	v, err := ValidateGraphQL(ProxyRequest{URL: url, GraphQLQuery: "{ user(id: 1) { email } }"})
+	if err != nil || !v.Valid || v.SchemaCached {
+		t.Errorf("validation without schema = %+v, %v", v, err)
+	}
+
+	info, err := IntrospectSchema(ProxyRequest{URL: url}, false)
+	if err != nil {
+		t.Fatalf("IntrospectSchema: %v", err)
+	}
+	if info.Cached || len(info.Operations.Query) != 1 || info.Operations.Query[0].Name != "user" ||
+		!strings.Contains(info.SDL, "type User {") {
+		t.Errorf("schema info = %+v", info)
+	}
+	if info, err = IntrospectSchema(ProxyRequest{URL: url + "?x=1"}, false); err != nil || !info.Cached {
+		t.Errorf("second introspection = %+v, %v", info, err)
+	}
+	if n := introspections.Load(); n != 1 {
+		t.Errorf("introspection requests = %d, want 1", n)
+	}
+
+	v, err = ValidateGraphQL(ProxyRequest{URL: url, GraphQLQuery: "{ user(id: 1) { email } }"})
+	if err != nil || v.Valid || !v.SchemaCached || len(v.Errors) == 0 {
+		t.Errorf("validation with schema = %+v, %v", v, err)
+	}
+
+	// 发送前按缓存的 schema 校验，校验失败时不发送
+	resp := SendRequest(ProxyRequest{Method: "POST", URL: url, BodyType: "graphql", GraphQLQuery: "{ user(id: 1) { email } }"})
+	if len(resp.GraphQLErrors) == 0 || resp.StatusCode != 0 {
+		t.Errorf("invalid query was sent: %+v", resp)
+	}
+	resp = SendRequest(ProxyRequest{Method: "POST", URL: url, BodyType: "graphql", GraphQLQuery: "{ user(id: 1) { email } }", SkipGraphQLValidation: true})
+	if resp.StatusCode != 200 {
+		t.Errorf("skip validation: %+v", resp)
+	}
+	resp = SendRequest(ProxyRequest{Method: "POST", URL: url, BodyType: "graphql", GraphQLQuery: "{ user(id: 1) { id name } }"})
+	if resp.Error != "" || resp.Body != `{"data": {"user": {"id": "1"}}}` {
+		t.Errorf("valid query: %+v", resp)
+	}
+
+	if err := ClearCachedSchema(url); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CachedSchema(url); err == nil {
+		t.Error("schema still cached after clear")
+	}
+}
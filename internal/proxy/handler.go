@@ -24,6 +24,7 @@ func HandleSend(w http.ResponseWriter, r *http.Request) {
 	// 这一步是同步调用的，如果请求很慢，这里会阻塞。
 	// 对于本地工具来说通常是可以接受的。
 	resp := SendRequest(req)
+	resp.HistoryID = recordHistory(req, resp)
 
 	// 4. 返回结果
 	// 注意：这里我们始终返回 200 OK (除非 JSON 解析失败)，
@@ -33,4 +34,17 @@ func HandleSend(w http.ResponseWriter, r *http.Request) {
 		// 如果写入 JSON 失败，通常意味着连接已断开，记录日志即可
 		// 这里暂不处理
 	}
-}
\ No newline at end of file
+}
+
+// HandleGetRuntimeVariables 获取 Web 界面共享的运行时变量 (提取器写入)
+func HandleGetRuntimeVariables(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessionVars.Snapshot())
+}
+
+// HandleClearRuntimeVariables 清空运行时变量
+func HandleClearRuntimeVariables(w http.ResponseWriter, r *http.Request) {
+	sessionVars.Clear()
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(`{"message": "Runtime variables cleared"}`))
+}
@@ -0,0 +1,19 @@
+package proxy
+
+import (
+	"go-api-tester/internal/database"
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB 为测试打开临时数据库，测试结束后关闭
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if err := database.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	t.Cleanup(func() {
+		database.DB.Close()
+		database.DB = nil
+	})
+}
@@ -0,0 +1,53 @@
+package proxy
+
+import (
+	"encoding/json"
+	"go-api-tester/internal/database"
+	"log"
+)
+
+// recordHistory 保存发送的请求 (变量替换前的模板与替换后实际发送的请求) 与收到的响应，失败时只记录日志
+func recordHistory(req ProxyRequest, resp ProxyResponse) int64 {
+	if database.DB == nil {
+		return 0
+	}
+	saved := &database.HistoryResponse{
+		StatusCode: resp.StatusCode,
+		Headers:    resp.Headers,
+		Body:       resp.Body,
+		IsBinary:   resp.IsBinary,
+		TimeMs:     resp.TimeMs,
+		Protocol:   resp.Protocol,
+		Error:      resp.Error,
+	}
+	if resp.Timing != nil {
+		saved.Timing, _ = json.Marshal(resp.Timing)
+	}
+
+	var envID int64
+	if env, err := loadEnvironment(req.EnvironmentID); err == nil && env != nil {
+		envID = env.ID
+	}
+
+	id, err := database.CreateHistoryWithResponse(toSavedRequest(req), sentRequest(resp.sent), envID, saved)
+	if err != nil {
+		log.Printf("[HISTORY] save failed: %v", err)
+		return 0
+	}
+	return id
+}
+
+// sentRequest 转换实际发送的请求: 查询参数合并进 URL，认证只保留类型 (凭据已在模板中保存)
+func sentRequest(req *ProxyRequest) *database.Request {
+	if req == nil {
+		return nil
+	}
+	sent := toSavedRequest(*req)
+	if u, err := buildURL(*req); err == nil {
+		sent.URL, sent.Name, sent.Params = u, u, nil
+	}
+	sent.Auth = database.AuthConfig{Type: req.Auth.Type}
+	sent.Variables, sent.Assertions, sent.Extractors = nil, nil, nil
+	sent.Scripts = database.Scripts{}
+	return sent
+}
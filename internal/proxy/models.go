@@ -1,35 +1,130 @@
 package proxy
 
+import (
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/graphql"
+)
+
 type KeyValue struct {
 	Key     string `json:"key"`
 	Value   string `json:"value"`
 	Enabled bool   `json:"enabled"`
-	Type    string `json:"type,omitempty"`
+	Type    string `json:"type,omitempty"` // form-data 中 "file" 表示 Value 为文件引用 (attachment:<id> 或本地路径)
+
+	ContentType string `json:"content_type,omitempty"` // form-data 分段的 Content-Type，文件缺省时按附件或扩展名推断
 }
 
 type ProxyRequest struct {
-	Method      string      `json:"method"`
-	URL         string      `json:"url"`
-	Params      []KeyValue  `json:"params"`
-	Headers     []KeyValue  `json:"headers"`
-	Auth        AuthConfig  `json:"auth"`
-	BodyType    string      `json:"body_type"`
-	RawBody     string      `json:"raw_body"`
-	FormData    []KeyValue  `json:"form_data"`
-	UrlEncoded  []KeyValue  `json:"url_encoded"`
+	Method     string     `json:"method"`
+	URL        string     `json:"url"`
+	Params     []KeyValue `json:"params"`
+	Headers    []KeyValue `json:"headers"`
+	Auth       AuthConfig `json:"auth"`
+	BodyType   string     `json:"body_type"`
+	RawBody    string     `json:"raw_body"`
+	FormData   []KeyValue `json:"form_data"`
+	UrlEncoded []KeyValue `json:"url_encoded"`
+	BinaryPath string     `json:"binary_path,omitempty"` // binary 类型的文件引用 (attachment:<id> 或本地路径)
+
+	// graphql 类型: 编码为 {query, variables, operationName}
+	// 目标地址已缓存 schema 时发送前先校验查询，SkipGraphQLValidation 可跳过
+	GraphQLQuery          string `json:"graphql_query,omitempty"`
+	GraphQLVars           string `json:"graphql_vars,omitempty"` // JSON 对象
+	GraphQLOperation      string `json:"graphql_operation,omitempty"`
+	SkipGraphQLValidation bool   `json:"graphql_skip_validation,omitempty"`
+
+	// 变量作用域，优先级: 运行时 > Variables > 分组链 (子分组覆盖父分组) > 环境 > 全局
+	// EnvironmentID 指定用于变量替换的环境，0 表示使用当前激活的环境
+	EnvironmentID int64      `json:"environment_id,omitempty"`
+	CollectionID  int64      `json:"collection_id,omitempty"`
+	Variables     []KeyValue `json:"variables,omitempty"`
+
+	// Assertions 响应断言，发送完成后评估，结果写入 ProxyResponse.Assertions
+	Assertions []database.Assertion `json:"assertions,omitempty"`
+
+	// Extractors 从响应中提取变量，结果写入 ProxyResponse.Extracted
+	Extractors []database.Extractor `json:"extractors,omitempty"`
+
+	// Settings 客户端设置 (超时、重定向、TLS 校验、HTTP 版本)，零值字段沿用全局设置
+	Settings database.ClientSettings `json:"settings"`
+
+	// Scripts 请求前与响应后脚本，所在分组链上的脚本先于请求脚本执行
+	Scripts database.Scripts `json:"scripts"`
+
+	// DisableCookies 为 true 时不使用持久化 Cookie (既不发送也不保存)
+	DisableCookies bool `json:"disable_cookies,omitempty"`
+
+	// Runtime 运行时变量作用域 (优先级最高)，为 nil 时使用 Web 界面共享的 SessionVariables
+	Runtime *VariableStore `json:"-"`
 }
 
 type AuthConfig struct {
-	Type   string            `json:"type"` 
+	Type   string            `json:"type"`
 	Basic  map[string]string `json:"basic"`
 	Bearer map[string]string `json:"bearer"`
+	APIKey map[string]string `json:"apikey,omitempty"`
+	Digest map[string]string `json:"digest,omitempty"`
+	HMAC   map[string]string `json:"hmac,omitempty"`
+	OAuth2 map[string]string `json:"oauth2,omitempty"`
+	AWSV4  map[string]string `json:"awsv4,omitempty"`
 }
 
 type ProxyResponse struct {
-	StatusCode  int                 `json:"status"`
-	Headers     map[string][]string `json:"headers"`
-	Body        string              `json:"body"`    // 如果是二进制，这里是 Base64 字符串
-	IsBinary    bool                `json:"is_binary"` // 新增：标记是否为二进制
-	TimeMs      int64               `json:"time_ms"`
-	Error       string              `json:"error,omitempty"`
-}
\ No newline at end of file
+	StatusCode int                 `json:"status"`
+	Headers    map[string][]string `json:"headers"`
+	Body       string              `json:"body"`      // 如果是二进制，这里是 Base64 字符串
+	IsBinary   bool                `json:"is_binary"` // 新增：标记是否为二进制
+	TimeMs     int64               `json:"time_ms"`
+	Timing     *Timing             `json:"timing,omitempty"` // 各阶段耗时 (DNS、连接、TLS、首字节、传输)
+	Error      string              `json:"error,omitempty"`
+
+	// UnresolvedVars 列出在任何作用域中都找不到的 {{变量}}，存在时请求不会被发送
+	UnresolvedVars []string `json:"unresolved_vars,omitempty"`
+
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+	Extracted  []ExtractResult   `json:"extracted,omitempty"`
+
+	GraphQLErrors []graphql.QueryError `json:"graphql_errors,omitempty"` // 发送前 GraphQL 校验失败的原因
+
+	Protocol  string        `json:"protocol,omitempty"`  // 实际使用的协议，例如 HTTP/2.0
+	Redirects []RedirectHop `json:"redirects,omitempty"` // 跟随的重定向链 (按发生顺序)
+
+	AuthSource *AuthSource `json:"auth_source,omitempty"` // 认证类型为 inherit 时实际使用的认证来源
+
+	ScriptLogs  []string `json:"script_logs,omitempty"`  // 脚本中 console.log 的输出
+	ScriptError string   `json:"script_error,omitempty"` // 响应后脚本的执行错误 (请求前脚本出错时写入 Error)
+
+	HistoryID int64 `json:"history_id,omitempty"` // 通过 /api/proxy/send 发送时写入的历史记录 ID
+
+	sent *ProxyRequest // 变量替换后的请求，供保存历史记录使用
+}
+
+// AuthSource 继承认证的解析结果
+type AuthSource struct {
+	CollectionID   int64  `json:"collection_id"` // 0 表示分组链上均未配置认证
+	CollectionName string `json:"collection_name,omitempty"`
+	Type           string `json:"type"` // 实际使用的认证类型
+}
+
+// RedirectHop 一次重定向
+type RedirectHop struct {
+	URL      string `json:"url"`      // 返回重定向的地址
+	Status   int    `json:"status"`   // 重定向状态码
+	Location string `json:"location"` // 跳转目标
+}
+
+// AssertionResult 单条断言的评估结果
+type AssertionResult struct {
+	database.Assertion
+	Passed  bool   `json:"passed"`
+	Actual  string `json:"actual,omitempty"`  // 实际值 (便于排查)
+	Message string `json:"message,omitempty"` // 失败原因
+}
+
+// ExtractResult 单个提取器的执行结果
+type ExtractResult struct {
+	Variable string `json:"variable"`
+	Value    string `json:"value,omitempty"`
+	Scope    string `json:"scope"` // 实际写入的作用域
+	Error    string `json:"error,omitempty"`
+}
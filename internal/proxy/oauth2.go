@@ -0,0 +1,363 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-api-tester/internal/database"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OAuth 2.0 授权方式
+const (
+	GrantClientCredentials = "client_credentials"
+	GrantAuthorizationCode = "authorization_code" // 固定使用 PKCE (S256)
+)
+
+// oauth2RefreshSkew 令牌在过期前多久即视为需要刷新
+const oauth2RefreshSkew = 30 * time.Second
+
+// oauth2AuthorizeTTL 授权请求 (state) 的有效期
+const oauth2AuthorizeTTL = 10 * time.Minute
+
+// OAuth2 配置项 (AuthConfig.OAuth2):
+//   - grant_type: client_credentials (默认) | authorization_code
+//   - token_url, client_id, client_secret, scope, audience
+//   - client_auth: header (默认，HTTP Basic) | body；未设置 client_secret 时 client_id 总是放在请求体中
+//   - auth_url, redirect_uri: 授权码方式使用，redirect_uri 默认为本服务的 /api/oauth2/callback
+//   - header_prefix: Authorization 请求头前缀，默认 Bearer
+
+// OAuth2TokenStatus 令牌状态 (不包含令牌本身)
+type OAuth2TokenStatus struct {
+	*database.OAuth2Token
+	Valid      bool  `json:"valid"`
+	ExpiresIn  int64 `json:"expires_in,omitempty"` // 剩余秒数，未声明过期时间时省略
+	HasRefresh bool  `json:"has_refresh_token"`
+}
+
+// NewOAuth2TokenStatus 计算令牌在当前时刻的状态
+func NewOAuth2TokenStatus(t *database.OAuth2Token) OAuth2TokenStatus {
+	now := time.Now()
+	s := OAuth2TokenStatus{OAuth2Token: t, Valid: t.ValidAt(now), HasRefresh: t.RefreshToken != ""}
+	if t.ExpiresAt != nil && s.Valid {
+		s.ExpiresIn = int64(t.ExpiresAt.Sub(now).Seconds())
+	}
+	return s
+}
+
+// oauth2CacheKey 由授权方式、令牌地址、client_id、scope、audience 计算缓存键
+func oauth2CacheKey(cfg map[string]string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		oauth2GrantType(cfg), cfg["token_url"], cfg["client_id"], cfg["scope"], cfg["audience"],
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func oauth2GrantType(cfg map[string]string) string {
+	return valueOr(cfg["grant_type"], GrantClientCredentials)
+}
+
+// oauth2Locks 按缓存键串行化令牌获取 (缓存键 -> *sync.Mutex)，
+// 避免并发请求重复向同一令牌端点取令牌，不同配置之间互不阻塞
+var oauth2Locks sync.Map
+
+// lockOAuth2 锁定缓存键并返回解锁函数
+func lockOAuth2(cfg map[string]string) func() {
+	mu, _ := oauth2Locks.LoadOrStore(oauth2CacheKey(cfg), &sync.Mutex{})
+	mu.(*sync.Mutex).Lock()
+	return mu.(*sync.Mutex).Unlock
+}
+
+// applyOAuth2 取得有效令牌 (缓存过期前自动刷新) 并写入 Authorization 请求头
+func applyOAuth2(r *http.Request, req ProxyRequest) error {
+	token, err := oauth2AccessToken(req)
+	if err != nil {
+		return err
+	}
+	r.Header.Set("Authorization", valueOr(req.Auth.OAuth2["header_prefix"], "Bearer")+" "+token.AccessToken)
+	return nil
+}
+
+// oauth2AccessToken 返回缓存中的有效令牌，即将过期时优先使用 refresh_token 刷新
+// client_credentials 方式在无法刷新时直接重新获取；授权码方式需要重新授权
+func oauth2AccessToken(req ProxyRequest) (*database.OAuth2Token, error) {
+	cfg := req.Auth.OAuth2
+	if cfg["token_url"] == "" {
+		return nil, fmt.Errorf("OAuth 2.0 缺少 token_url")
+	}
+	grant := oauth2GrantType(cfg)
+	if grant != GrantClientCredentials && grant != GrantAuthorizationCode {
+		return nil, fmt.Errorf("不支持的 OAuth 2.0 授权方式: %s", grant)
+	}
+	if database.DB == nil {
+		return nil, fmt.Errorf("OAuth 2.0 令牌缓存不可用")
+	}
+
+	defer lockOAuth2(cfg)()
+
+	cached, err := database.GetOAuth2Token(oauth2CacheKey(cfg))
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if cached != nil && cached.ValidAt(time.Now().Add(oauth2RefreshSkew)) {
+		return cached, nil
+	}
+
+	var refreshErr error
+	if cached != nil && cached.RefreshToken != "" {
+		form := url.Values{"grant_type": {"refresh_token"}, "refresh_token": {cached.RefreshToken}}
+		token, err := requestOAuth2Token(req, form)
+		if err == nil {
+			if token.RefreshToken == "" {
+				token.RefreshToken = cached.RefreshToken // 服务端未轮换 refresh_token 时继续使用旧值
+			}
+			return token, saveOAuth2Token(cfg, token)
+		}
+		refreshErr = err
+	}
+
+	if grant == GrantAuthorizationCode {
+		if refreshErr != nil {
+			return nil, fmt.Errorf("刷新令牌失败，请重新授权: %v", refreshErr)
+		}
+		return nil, fmt.Errorf("没有可用的令牌，请先通过 /api/oauth2/authorize 完成授权")
+	}
+	return fetchClientCredentials(req)
+}
+
+// FetchOAuth2Token 忽略缓存重新获取令牌 (仅 client_credentials)，请求先做变量替换
+func FetchOAuth2Token(req ProxyRequest) (*database.OAuth2Token, error) {
+	if err := resolveRequest(&req); err != nil {
+		return nil, err
+	}
+	if req.Auth.Type != AuthOAuth2 || req.Auth.OAuth2["token_url"] == "" {
+		return nil, fmt.Errorf("请求未配置 OAuth 2.0 令牌地址")
+	}
+	if oauth2GrantType(req.Auth.OAuth2) != GrantClientCredentials {
+		return nil, fmt.Errorf("授权码方式请通过 /api/oauth2/authorize 获取令牌")
+	}
+	defer lockOAuth2(req.Auth.OAuth2)()
+	return fetchClientCredentials(req)
+}
+
+func fetchClientCredentials(req ProxyRequest) (*database.OAuth2Token, error) {
+	cfg := req.Auth.OAuth2
+	form := url.Values{"grant_type": {GrantClientCredentials}}
+	if cfg["scope"] != "" {
+		form.Set("scope", cfg["scope"])
+	}
+	if cfg["audience"] != "" {
+		form.Set("audience", cfg["audience"])
+	}
+	token, err := requestOAuth2Token(req, form)
+	if err != nil {
+		return nil, err
+	}
+	return token, saveOAuth2Token(cfg, token)
+}
+
+func saveOAuth2Token(cfg map[string]string, t *database.OAuth2Token) error {
+	t.CacheKey = oauth2CacheKey(cfg)
+	t.GrantType = oauth2GrantType(cfg)
+	t.TokenURL = cfg["token_url"]
+	t.ClientID = cfg["client_id"]
+	t.Scope = cfg["scope"]
+	if err := database.SaveOAuth2Token(t); err != nil {
+		return fmt.Errorf("保存令牌失败: %v", err)
+	}
+	return nil
+}
+
+// requestOAuth2Token 向令牌端点发送请求 (RFC 6749 第 4、6 节)
+// 沿用原请求的环境、客户端设置、证书与上游代理配置，不使用持久化 Cookie
+func requestOAuth2Token(req ProxyRequest, form url.Values) (*database.OAuth2Token, error) {
+	cfg := req.Auth.OAuth2
+	clientID, secret := cfg["client_id"], cfg["client_secret"]
+
+	tokenReq := ProxyRequest{
+		Method:         "POST",
+		URL:            cfg["token_url"],
+		Headers:        []KeyValue{{Key: "Accept", Value: "application/json", Enabled: true}},
+		BodyType:       "x-www-form-urlencoded",
+		EnvironmentID:  req.EnvironmentID,
+		Settings:       req.Settings,
+		DisableCookies: true,
+	}
+	if secret != "" && valueOr(cfg["client_auth"], "header") == "header" {
+		// RFC 6749 2.3.1: 凭据需先做 form 编码
+		tokenReq.Auth = AuthConfig{Type: AuthBasic, Basic: map[string]string{
+			"username": url.QueryEscape(clientID),
+			"password": url.QueryEscape(secret),
+		}}
+	} else {
+		form.Set("client_id", clientID)
+		if secret != "" {
+			form.Set("client_secret", secret)
+		}
+	}
+	for k, vals := range form {
+		for _, v := range vals {
+			tokenReq.UrlEncoded = append(tokenReq.UrlEncoded, KeyValue{Key: k, Value: v, Enabled: true})
+		}
+	}
+
+	resp := doRequest(tokenReq)
+	if resp.Error != "" {
+		return nil, fmt.Errorf("令牌请求失败: %s", resp.Error)
+	}
+	return parseOAuth2Token(resp, time.Now())
+}
+
+// parseOAuth2Token 解析令牌响应，兼容 JSON 与 application/x-www-form-urlencoded 两种格式
+func parseOAuth2Token(resp ProxyResponse, now time.Time) (*database.OAuth2Token, error) {
+	if resp.IsBinary {
+		return nil, fmt.Errorf("令牌响应不是文本")
+	}
+	fields := make(map[string]string)
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(resp.Body), &raw); err == nil {
+		for k, v := range raw {
+			switch v := v.(type) {
+			case string:
+				fields[k] = v
+			case float64:
+				fields[k] = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+		}
+	} else if values, err := url.ParseQuery(resp.Body); err == nil {
+		for k := range values {
+			fields[k] = values.Get(k)
+		}
+	}
+
+	if fields["error"] != "" {
+		msg := fields["error"]
+		if desc := fields["error_description"]; desc != "" {
+			msg += ": " + desc
+		}
+		return nil, fmt.Errorf("令牌端点返回 %d (%s)", resp.StatusCode, msg)
+	}
+	if resp.StatusCode >= 400 || fields["access_token"] == "" {
+		return nil, fmt.Errorf("令牌端点返回 %d: %s", resp.StatusCode, truncate(resp.Body, 200))
+	}
+
+	t := &database.OAuth2Token{
+		AccessToken:  fields["access_token"],
+		RefreshToken: fields["refresh_token"],
+		TokenType:    fields["token_type"],
+	}
+	if s := fields["expires_in"]; s != "" {
+		secs, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的 expires_in: %s", s)
+		}
+		exp := now.Add(time.Duration(secs * float64(time.Second))).UTC()
+		t.ExpiresAt = &exp
+	}
+	return t, nil
+}
+
+// ---------------------------------------------------------------------------
+// 授权码 + PKCE (RFC 7636)
+// ---------------------------------------------------------------------------
+
+// pendingAuthorization 等待回调的授权请求，保存已替换变量的请求以便换取令牌
+type pendingAuthorization struct {
+	req         ProxyRequest
+	verifier    string
+	redirectURI string
+	createdAt   time.Time
+}
+
+var (
+	pendingMu      sync.Mutex
+	pendingByState = make(map[string]*pendingAuthorization)
+)
+
+// StartOAuth2Authorization 生成 PKCE 校验码与 state，返回需要在浏览器中打开的授权地址
+// 配置未指定 redirect_uri 时使用 defaultRedirect (本服务的回调地址)
+func StartOAuth2Authorization(req ProxyRequest, defaultRedirect string) (string, error) {
+	if err := resolveRequest(&req); err != nil {
+		return "", err
+	}
+	cfg := req.Auth.OAuth2
+	if req.Auth.Type != AuthOAuth2 || oauth2GrantType(cfg) != GrantAuthorizationCode {
+		return "", fmt.Errorf("请求未配置 OAuth 2.0 授权码方式")
+	}
+	if cfg["auth_url"] == "" || cfg["token_url"] == "" || cfg["client_id"] == "" {
+		return "", fmt.Errorf("授权码方式需要 auth_url、token_url 与 client_id")
+	}
+	authURL, err := url.Parse(cfg["auth_url"])
+	if err != nil {
+		return "", fmt.Errorf("无效的 auth_url: %v", err)
+	}
+
+	pending := &pendingAuthorization{
+		req:         req,
+		verifier:    randomHex(32), // 64 个十六进制字符，满足 43~128 位的长度要求
+		redirectURI: valueOr(cfg["redirect_uri"], defaultRedirect),
+		createdAt:   time.Now(),
+	}
+	state := randomHex(16)
+	challenge := sha256.Sum256([]byte(pending.verifier))
+
+	q := authURL.Query()
+	q.Set("response_type", "code")
+	q.Set("client_id", cfg["client_id"])
+	q.Set("redirect_uri", pending.redirectURI)
+	q.Set("state", state)
+	q.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	q.Set("code_challenge_method", "S256")
+	if cfg["scope"] != "" {
+		q.Set("scope", cfg["scope"])
+	}
+	if cfg["audience"] != "" {
+		q.Set("audience", cfg["audience"])
+	}
+	authURL.RawQuery = q.Encode()
+
+	pendingMu.Lock()
+	defer pendingMu.Unlock()
+	for s, p := range pendingByState {
+		if time.Since(p.createdAt) > oauth2AuthorizeTTL {
+			delete(pendingByState, s)
+		}
+	}
+	pendingByState[state] = pending
+	return authURL.String(), nil
+}
+
+// CompleteOAuth2Authorization 处理授权回调: 校验 state，用授权码与 PKCE 校验码换取令牌并缓存
+func CompleteOAuth2Authorization(state, code string) (*database.OAuth2Token, error) {
+	pendingMu.Lock()
+	pending, ok := pendingByState[state]
+	delete(pendingByState, state)
+	pendingMu.Unlock()
+	if !ok || time.Since(pending.createdAt) > oauth2AuthorizeTTL {
+		return nil, fmt.Errorf("授权请求不存在或已过期 (state 无效)")
+	}
+	if code == "" {
+		return nil, fmt.Errorf("回调缺少授权码")
+	}
+
+	form := url.Values{
+		"grant_type":    {GrantAuthorizationCode},
+		"code":          {code},
+		"redirect_uri":  {pending.redirectURI},
+		"code_verifier": {pending.verifier},
+	}
+	defer lockOAuth2(pending.req.Auth.OAuth2)()
+	token, err := requestOAuth2Token(pending.req, form)
+	if err != nil {
+		return nil, err
+	}
+	return token, saveOAuth2Token(pending.req.Auth.OAuth2, token)
+}
@@ -0,0 +1,339 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// tokenServer 记录收到的令牌请求，响应由 reply 决定
+type tokenServer struct {
+	*httptest.Server
+	mu    sync.Mutex
+	forms []url.Values
+	auths []string
+	reply func(form url.Values) (int, string, string) // 状态码、Content-Type、响应体
+}
+
+func newTokenServer(t *testing.T, reply func(form url.Values) (int, string, string)) *tokenServer {
+	s := &tokenServer{reply: reply}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("token request method = %s, want POST", r.Method)
+		}
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("parse form: %v", err)
+		}
+		s.mu.Lock()
+		s.forms = append(s.forms, r.PostForm)
+		s.auths = append(s.auths, r.Header.Get("Authorization"))
+		s.mu.Unlock()
+		status, ctype, body := s.reply(r.PostForm)
+		w.Header().Set("Content-Type", ctype)
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *tokenServer) calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.forms)
+}
+
+func (s *tokenServer) form(i int) url.Values {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.forms[i]
+}
+
+func oauth2Request(cfg map[string]string) ProxyRequest {
+	return ProxyRequest{Method: "GET", URL: "http://example.invalid/", Auth: AuthConfig{Type: AuthOAuth2, OAuth2: cfg}}
+}
+
+func jsonToken(v map[string]interface{}) (int, string, string) {
+	b, _ := json.Marshal(v)
+	return http.StatusOK, "application/json", string(b)
+}
+
+func TestOAuth2ClientCredentials(t *testing.T) {
+	openTestDB(t)
+	srv := newTokenServer(t, func(form url.Values) (int, string, string) {
+		return jsonToken(map[string]interface{}{"access_token": "tok-1", "token_type": "Bearer", "expires_in": 3600})
+	})
+	req := oauth2Request(map[string]string{
+		"token_url":     srv.URL,
+		"client_id":     "my client",
+		"client_secret": "s&cret",
+		"scope":         "read write",
+	})
+
+	r := httptest.NewRequest("GET", "http://example.invalid/", nil)
+	if err := applyOAuth2(r, req); err != nil {
+		t.Fatalf("applyOAuth2: %v", err)
+	}
+	if got := r.Header.Get("Authorization"); got != "Bearer tok-1" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer tok-1")
+	}
+
+	form := srv.form(0)
+	if form.Get("grant_type") != "client_credentials" || form.Get("scope") != "read write" {
+		t.Errorf("form = %v", form)
+	}
+	if form.Has("client_id") || form.Has("client_secret") {
+		t.Errorf("credentials sent in body with client_auth=header: %v", form)
+	}
+	// RFC 6749 2.3.1: 先做 form 编码再做 Basic 编码
+	want := "Basic " + base64.StdEncoding.EncodeToString([]byte("my+client:s%26cret"))
+	if srv.auths[0] != want {
+		t.Errorf("token Authorization = %q, want %q", srv.auths[0], want)
+	}
+
+	// 第二次使用缓存，不再请求令牌端点
+	if _, err := oauth2AccessToken(req); err != nil {
+		t.Fatalf("cached token: %v", err)
+	}
+	if n := srv.calls(); n != 1 {
+		t.Errorf("token endpoint called %d times, want 1", n)
+	}
+}
+
+func TestOAuth2ClientAuthBody(t *testing.T) {
+	openTestDB(t)
+	srv := newTokenServer(t, func(form url.Values) (int, string, string) {
+		return jsonToken(map[string]interface{}{"access_token": "tok"})
+	})
+	req := oauth2Request(map[string]string{
+		"token_url":     srv.URL,
+		"client_id":     "cid",
+		"client_secret": "secret",
+		"client_auth":   "body",
+	})
+	if _, err := oauth2AccessToken(req); err != nil {
+		t.Fatalf("oauth2AccessToken: %v", err)
+	}
+	form := srv.form(0)
+	if form.Get("client_id") != "cid" || form.Get("client_secret") != "secret" || srv.auths[0] != "" {
+		t.Errorf("form = %v, Authorization = %q", form, srv.auths[0])
+	}
+}
+
+func TestOAuth2RefreshBeforeExpiry(t *testing.T) {
+	openTestDB(t)
+	srv := newTokenServer(t, func(form url.Values) (int, string, string) {
+		switch form.Get("grant_type") {
+		case "client_credentials":
+			// 有效期短于 oauth2RefreshSkew，下次使用前即需刷新
+			return jsonToken(map[string]interface{}{"access_token": "tok-1", "refresh_token": "rt-1", "expires_in": 10})
+		case "refresh_token":
+			// 不轮换 refresh_token
+			return jsonToken(map[string]interface{}{"access_token": "tok-2", "expires_in": 10})
+		}
+		return http.StatusBadRequest, "application/json", `{"error":"unsupported_grant_type"}`
+	})
+	req := oauth2Request(map[string]string{"token_url": srv.URL, "client_id": "cid", "client_secret": "secret"})
+
+	if tok, err := oauth2AccessToken(req); err != nil || tok.AccessToken != "tok-1" {
+		t.Fatalf("first token = %+v, %v", tok, err)
+	}
+	tok, err := oauth2AccessToken(req)
+	if err != nil || tok.AccessToken != "tok-2" {
+		t.Fatalf("refreshed token = %+v, %v", tok, err)
+	}
+	if form := srv.form(1); form.Get("grant_type") != "refresh_token" || form.Get("refresh_token") != "rt-1" {
+		t.Errorf("refresh form = %v", form)
+	}
+
+	// 未轮换时继续使用旧的 refresh_token
+	tok, err = oauth2AccessToken(req)
+	if err != nil || tok.AccessToken != "tok-2" || tok.RefreshToken != "rt-1" {
+		t.Fatalf("second refresh = %+v, %v", tok, err)
+	}
+	if form := srv.form(2); form.Get("refresh_token") != "rt-1" {
+		t.Errorf("second refresh form = %v", form)
+	}
+	if n := srv.calls(); n != 3 {
+		t.Errorf("token endpoint called %d times, want 3", n)
+	}
+}
+
+func TestOAuth2AuthorizationCodePKCE(t *testing.T) {
+	openTestDB(t)
+	var challenge string
+	srv := newTokenServer(t, func(form url.Values) (int, string, string) {
+		sum := sha256.Sum256([]byte(form.Get("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != challenge {
+			return http.StatusBadRequest, "application/json", `{"error":"invalid_grant","error_description":"PKCE verification failed"}`
+		}
+		return http.StatusOK, "application/x-www-form-urlencoded", "access_token=code-tok&refresh_token=rt&expires_in=3600"
+	})
+	req := oauth2Request(map[string]string{
+		"grant_type": GrantAuthorizationCode,
+		"auth_url":   "https://auth.example.com/authorize?prompt=consent",
+		"token_url":  srv.URL,
+		"client_id":  "cid",
+		"scope":      "openid",
+	})
+
+	// 授权前没有可用令牌
+	if _, err := oauth2AccessToken(req); err == nil {
+		t.Fatal("expected error before authorization")
+	}
+
+	raw, err := StartOAuth2Authorization(req, "http://localhost:8080/api/oauth2/callback")
+	if err != nil {
+		t.Fatalf("StartOAuth2Authorization: %v", err)
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("parse authorize url: %v", err)
+	}
+	q := u.Query()
+	if q.Get("response_type") != "code" || q.Get("client_id") != "cid" || q.Get("scope") != "openid" ||
+		q.Get("prompt") != "consent" || q.Get("code_challenge_method") != "S256" ||
+		q.Get("redirect_uri") != "http://localhost:8080/api/oauth2/callback" {
+		t.Errorf("authorize query = %v", q)
+	}
+	state, challenge := q.Get("state"), q.Get("code_challenge")
+	if state == "" || challenge == "" {
+		t.Fatalf("missing state or challenge: %v", q)
+	}
+
+	if _, err := CompleteOAuth2Authorization("wrong-state", "code"); err == nil {
+		t.Error("expected error for unknown state")
+	}
+	tok, err := CompleteOAuth2Authorization(state, "the-code")
+	if err != nil {
+		t.Fatalf("CompleteOAuth2Authorization: %v", err)
+	}
+	if tok.AccessToken != "code-tok" || tok.RefreshToken != "rt" {
+		t.Errorf("token = %+v", tok)
+	}
+	form := srv.form(0)
+	if form.Get("grant_type") != GrantAuthorizationCode || form.Get("code") != "the-code" ||
+		form.Get("redirect_uri") != "http://localhost:8080/api/oauth2/callback" || form.Get("client_id") != "cid" {
+		t.Errorf("token form = %v", form)
+	}
+
+	// state 只能使用一次
+	if _, err := CompleteOAuth2Authorization(state, "the-code"); err == nil {
+		t.Error("expected error when state is reused")
+	}
+	// 授权完成后令牌进入缓存
+	if cached, err := oauth2AccessToken(req); err != nil || cached.AccessToken != "code-tok" {
+		t.Errorf("cached token = %+v, %v", cached, err)
+	}
+}
+
+func TestOAuth2AudienceAndLocking(t *testing.T) {
+	openTestDB(t)
+	release := make(chan struct{})
+	srv := newTokenServer(t, func(form url.Values) (int, string, string) {
+		if form.Get("audience") == "slow" {
+			<-release
+		}
+		return jsonToken(map[string]interface{}{"access_token": "tok-" + form.Get("audience"), "expires_in": 3600})
+	})
+	cfg := func(audience string) ProxyRequest {
+		return oauth2Request(map[string]string{"token_url": srv.URL, "client_id": "cid", "audience": audience})
+	}
+
+	// 同一配置的并发请求只向令牌端点请求一次
+	var wg sync.WaitGroup
+	tokens := make([]string, 5)
+	for i := range tokens {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if tok, err := oauth2AccessToken(cfg("slow")); err == nil {
+				tokens[i] = tok.AccessToken
+			}
+		}()
+	}
+
+	// 另一个 audience 不共享令牌，也不等待正在进行的取令牌请求
+	done := make(chan string)
+	go func() {
+		tok, err := oauth2AccessToken(cfg("fast"))
+		if err != nil {
+			done <- err.Error()
+			return
+		}
+		done <- tok.AccessToken
+	}()
+	select {
+	case got := <-done:
+		if got != "tok-fast" {
+			t.Errorf("fast audience token = %q", got)
+		}
+	case <-time.After(5 * time.Second):
+		close(release)
+		t.Fatal("token fetch blocked by another configuration")
+	}
+
+	close(release)
+	wg.Wait()
+	for i, tok := range tokens {
+		if tok != "tok-slow" {
+			t.Errorf("token %d = %q", i, tok)
+		}
+	}
+	if n := srv.calls(); n != 2 {
+		t.Errorf("token endpoint called %d times, want 2", n)
+	}
+}
+
+func TestParseOAuth2Token(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		access  string
+		refresh string
+		expires time.Duration // 0 表示未声明过期时间
+		errPart string
+	}{
+		{name: "json", status: 200, body: `{"access_token":"a","refresh_token":"r","token_type":"Bearer","expires_in":3600}`, access: "a", refresh: "r", expires: time.Hour},
+		{name: "json string expires_in", status: 200, body: `{"access_token":"a","expires_in":"60"}`, access: "a", expires: time.Minute},
+		{name: "form", status: 200, body: "access_token=a%2Bb&token_type=bearer&expires_in=120", access: "a+b", expires: 2 * time.Minute},
+		{name: "no expiry", status: 200, body: `{"access_token":"a"}`, access: "a"},
+		{name: "error", status: 400, body: `{"error":"invalid_client","error_description":"bad secret"}`, errPart: "invalid_client: bad secret"},
+		{name: "form error", status: 200, body: "error=access_denied", errPart: "access_denied"},
+		{name: "missing token", status: 200, body: `{"token_type":"Bearer"}`, errPart: "200"},
+		{name: "http error", status: 500, body: "oops", errPart: "500: oops"},
+		{name: "bad expires_in", status: 200, body: `{"access_token":"a","expires_in":"soon"}`, errPart: "expires_in"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tok, err := parseOAuth2Token(ProxyResponse{StatusCode: tt.status, Body: tt.body}, now)
+			if tt.errPart != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.errPart) {
+					t.Fatalf("err = %v, want containing %q", err, tt.errPart)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tok.AccessToken != tt.access || tok.RefreshToken != tt.refresh {
+				t.Errorf("token = %+v", tok)
+			}
+			switch {
+			case tt.expires == 0 && tok.ExpiresAt != nil:
+				t.Errorf("ExpiresAt = %v, want nil", tok.ExpiresAt)
+			case tt.expires != 0 && (tok.ExpiresAt == nil || !tok.ExpiresAt.Equal(now.Add(tt.expires))):
+				t.Errorf("ExpiresAt = %v, want %v", tok.ExpiresAt, now.Add(tt.expires))
+			}
+		})
+	}
+}
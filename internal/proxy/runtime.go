@@ -0,0 +1,56 @@
+package proxy
+
+import "sync"
+
+// VariableStore 运行时变量作用域，只保存在内存中
+// 集合运行器为每次运行创建独立的 Store；Web 界面的单次发送共享 sessionVars
+type VariableStore struct {
+	mu   sync.RWMutex
+	vars map[string]string
+}
+
+// NewVariableStore 创建空的运行时变量作用域
+func NewVariableStore() *VariableStore {
+	return &VariableStore{vars: make(map[string]string)}
+}
+
+// sessionVars Web 界面共享的运行时变量，进程退出后丢失
+var sessionVars = NewVariableStore()
+
+// SessionVariables 返回 Web 界面共享的运行时变量作用域
+func SessionVariables() *VariableStore {
+	return sessionVars
+}
+
+// Set 设置变量
+func (s *VariableStore) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vars[key] = value
+}
+
+// Snapshot 返回当前所有变量的副本
+func (s *VariableStore) Snapshot() map[string]string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]string, len(s.vars))
+	for k, v := range s.vars {
+		out[k] = v
+	}
+	return out
+}
+
+// Clear 清空所有变量
+func (s *VariableStore) Clear() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.vars = make(map[string]string)
+}
+
+// runtime 返回请求使用的运行时变量作用域
+func (r *ProxyRequest) runtime() *VariableStore {
+	if r.Runtime != nil {
+		return r.Runtime
+	}
+	return sessionVars
+}
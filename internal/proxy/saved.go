@@ -0,0 +1,105 @@
+package proxy
+
+import "go-api-tester/internal/database"
+
+// NewProxyRequest 将已保存的请求转换为可直接发送的 ProxyRequest
+// 与前端 sendRequest 中构造 payload 的逻辑保持一致
+func NewProxyRequest(saved *database.Request) ProxyRequest {
+	return ProxyRequest{
+		Method:   saved.Method,
+		URL:      saved.URL,
+		Params:   fromSavedKV(saved.Params),
+		Headers:  fromSavedKV(saved.Headers),
+		Auth:     fromSavedAuth(saved.Auth),
+		BodyType: saved.Body.Type,
+		RawBody:  saved.Body.RawContent,
+
+		FormData:   fromSavedKV(saved.Body.FormData),
+		UrlEncoded: fromSavedKV(saved.Body.UrlEncoded),
+		BinaryPath: saved.Body.BinaryPath,
+
+		GraphQLQuery:     saved.Body.GraphQLQuery,
+		GraphQLVars:      saved.Body.GraphQLVars,
+		GraphQLOperation: saved.Body.GraphQLOperation,
+
+		CollectionID: saved.CollectionID,
+		Variables:    fromSavedKV(saved.Variables),
+		Assertions:   saved.Assertions,
+		Extractors:   saved.Extractors,
+		Settings:     saved.Settings,
+		Scripts:      saved.Scripts,
+	}
+}
+
+func fromSavedAuth(a database.AuthConfig) AuthConfig {
+	return AuthConfig{
+		Type:   a.Type,
+		Basic:  a.Basic,
+		Bearer: a.Bearer,
+		APIKey: a.APIKey,
+		Digest: a.Digest,
+		HMAC:   a.HMAC,
+		OAuth2: a.OAuth2,
+		AWSV4:  a.AWSV4,
+	}
+}
+
+func fromSavedKV(list []database.KeyValue) []KeyValue {
+	if list == nil {
+		return nil
+	}
+	out := make([]KeyValue, len(list))
+	for i, kv := range list {
+		out[i] = KeyValue{Key: kv.Key, Value: kv.Value, Enabled: kv.Enabled, Type: kv.Type, ContentType: kv.ContentType}
+	}
+	return out
+}
+
+// toSavedRequest 将 ProxyRequest 转换为可保存的请求 (NewProxyRequest 的逆操作)
+func toSavedRequest(req ProxyRequest) *database.Request {
+	return &database.Request{
+		CollectionID: req.CollectionID,
+		Name:         req.URL,
+		Method:       req.Method,
+		URL:          req.URL,
+		Params:       toSavedKV(req.Params),
+		Headers:      toSavedKV(req.Headers),
+		Auth: database.AuthConfig{
+			Type:   req.Auth.Type,
+			Basic:  req.Auth.Basic,
+			Bearer: req.Auth.Bearer,
+			APIKey: req.Auth.APIKey,
+			Digest: req.Auth.Digest,
+			HMAC:   req.Auth.HMAC,
+			OAuth2: req.Auth.OAuth2,
+			AWSV4:  req.Auth.AWSV4,
+		},
+		Body: database.BodyConfig{
+			Type:       req.BodyType,
+			RawContent: req.RawBody,
+			FormData:   toSavedKV(req.FormData),
+			UrlEncoded: toSavedKV(req.UrlEncoded),
+			BinaryPath: req.BinaryPath,
+
+			GraphQLQuery:     req.GraphQLQuery,
+			GraphQLVars:      req.GraphQLVars,
+			GraphQLOperation: req.GraphQLOperation,
+		},
+		Variables:  toSavedKV(req.Variables),
+		Assertions: req.Assertions,
+		Extractors: req.Extractors,
+		Settings:   req.Settings,
+		Scripts:    req.Scripts,
+	}
+}
+
+func toSavedKV(list []KeyValue) []database.KeyValue {
+	if list == nil {
+		return nil
+	}
+	out := make([]database.KeyValue, len(list))
+	for i, kv := range list {
+		out[i] = database.KeyValue{Key: kv.Key, Value: kv.Value, Enabled: kv.Enabled, Type: kv.Type, ContentType: kv.ContentType}
+	}
+	return out
+}
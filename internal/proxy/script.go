@@ -0,0 +1,205 @@
+package proxy
+
+import (
+	"fmt"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/script"
+	"sort"
+	"strings"
+	"time"
+)
+
+// scriptSource 一段待执行的脚本及其来源 (用于错误信息)
+type scriptSource struct {
+	origin  string
+	scripts database.Scripts
+}
+
+// scriptRun 一次发送过程中的脚本执行状态
+// 执行顺序: 分组脚本 (根到叶) -> 请求脚本，请求前与响应后脚本均按此顺序
+type scriptRun struct {
+	sources []scriptSource
+	timeout time.Duration
+	logs    []string
+	tests   []script.TestResult
+}
+
+// preRequest 加载分组链与请求上的脚本并执行请求前脚本，脚本对 req 的修改写回请求
+func (s *scriptRun) preRequest(req *ProxyRequest) error {
+	if req.CollectionID != 0 && database.DB != nil {
+		path, err := database.GetCollectionPath(req.CollectionID)
+		if err != nil {
+			return err
+		}
+		for _, c := range path {
+			if c.Scripts != nil {
+				s.sources = append(s.sources, scriptSource{origin: "分组 " + c.Name, scripts: *c.Scripts})
+			}
+		}
+	}
+	s.sources = append(s.sources, scriptSource{origin: "当前请求", scripts: req.Scripts})
+
+	var env *scriptEnv
+	for _, src := range s.sources {
+		if strings.TrimSpace(src.scripts.PreRequest) == "" {
+			continue
+		}
+		if env == nil {
+			var err error
+			if env, err = s.prepare(*req); err != nil {
+				return err
+			}
+		}
+		sr := scriptRequest(*req)
+		err := s.run(src.scripts.PreRequest, &script.Context{Request: sr, Env: env})
+		if err != nil {
+			return fmt.Errorf("请求前脚本 (%s): %v", src.origin, err)
+		}
+		applyScriptRequest(req, sr)
+	}
+	return nil
+}
+
+// postResponse 执行响应后脚本 (请求失败时跳过)，test() 结果追加到断言结果中
+func (s *scriptRun) postResponse(req ProxyRequest, resp *ProxyResponse) {
+	if resp.Error == "" && len(s.sources) > 0 {
+		sr := &script.Response{
+			Status:  resp.StatusCode,
+			Headers: make(map[string]string, len(resp.Headers)),
+			Body:    resp.Body,
+			TimeMs:  resp.TimeMs,
+		}
+		for k, v := range resp.Headers {
+			if len(v) > 0 {
+				sr.Headers[k] = v[0]
+			}
+		}
+
+		var env *scriptEnv
+		var err error
+		for _, src := range s.sources {
+			if strings.TrimSpace(src.scripts.PostResponse) == "" {
+				continue
+			}
+			if env == nil {
+				if env, err = s.prepare(req); err != nil {
+					break
+				}
+			}
+			if err = s.run(src.scripts.PostResponse, &script.Context{Request: scriptRequest(req), Response: sr, Env: env}); err != nil {
+				err = fmt.Errorf("响应后脚本 (%s): %v", src.origin, err)
+				break
+			}
+		}
+		if err != nil {
+			resp.ScriptError = err.Error()
+		}
+	}
+
+	resp.ScriptLogs = s.logs
+	for _, t := range s.tests {
+		resp.Assertions = append(resp.Assertions, AssertionResult{
+			Assertion: database.Assertion{Type: database.AssertScript, Target: t.Name, Enabled: true},
+			Passed:    t.Passed,
+			Message:   t.Message,
+		})
+	}
+}
+
+// prepare 在第一次执行脚本前加载超时设置与变量
+func (s *scriptRun) prepare(req ProxyRequest) (*scriptEnv, error) {
+	settings, err := effectiveSettings(req)
+	if err != nil {
+		return nil, err
+	}
+	s.timeout = time.Duration(settings.ScriptTimeoutMs) * time.Millisecond
+	return newScriptEnv(req)
+}
+
+func (s *scriptRun) run(code string, ctx *script.Context) error {
+	result, err := script.Run(code, ctx, s.timeout)
+	s.logs = append(s.logs, result.Logs...)
+	s.tests = append(s.tests, result.Tests...)
+	return err
+}
+
+// scriptRequest 将请求转换为脚本中的 req 对象 (请求头只包含启用的项)
+func scriptRequest(req ProxyRequest) *script.Request {
+	sr := &script.Request{Method: req.Method, URL: req.URL, Headers: make(map[string]string), Body: req.RawBody}
+	for _, h := range req.Headers {
+		if h.Enabled && h.Key != "" {
+			sr.Headers[h.Key] = h.Value
+		}
+	}
+	return sr
+}
+
+// applyScriptRequest 写回脚本的修改: 已有请求头更新或停用，新增的请求头追加在末尾
+func applyScriptRequest(req *ProxyRequest, sr *script.Request) {
+	req.Method = sr.Method
+	req.URL = sr.URL
+	req.RawBody = sr.Body
+
+	headers := make([]KeyValue, 0, len(req.Headers)+len(sr.Headers))
+	seen := make(map[string]bool)
+	for _, h := range req.Headers {
+		if h.Enabled && h.Key != "" {
+			value, ok := sr.Headers[h.Key]
+			h.Enabled = ok
+			if ok {
+				h.Value = value
+				seen[h.Key] = true
+			}
+		}
+		headers = append(headers, h)
+	}
+	added := make([]string, 0, len(sr.Headers))
+	for k := range sr.Headers {
+		if !seen[k] {
+			added = append(added, k)
+		}
+	}
+	sort.Strings(added)
+	for _, k := range added {
+		headers = append(headers, KeyValue{Key: k, Value: sr.Headers[k], Enabled: true})
+	}
+	req.Headers = headers
+}
+
+// scriptEnv 脚本中的 env 对象，写入规则与提取器一致
+type scriptEnv struct {
+	req  ProxyRequest
+	vars map[string]string
+}
+
+func newScriptEnv(req ProxyRequest) (*scriptEnv, error) {
+	vars, err := loadVariables(req)
+	if err != nil {
+		return nil, err
+	}
+	return &scriptEnv{req: req, vars: vars}, nil
+}
+
+func (e *scriptEnv) Get(name string) (string, bool) {
+	v, ok := e.vars[name]
+	return v, ok
+}
+
+// Set 写入运行时变量，scope 为 environment 时同时写回当前环境
+func (e *scriptEnv) Set(name, value, scope string) error {
+	if name == "" {
+		return fmt.Errorf("变量名不能为空")
+	}
+	switch scope {
+	case "", database.ScopeRuntime:
+	case database.ScopeEnvironment:
+		if err := saveToEnvironment(e.req.EnvironmentID, name, value); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("未知的变量作用域: %s", scope)
+	}
+	e.vars[name] = value
+	e.req.runtime().Set(name, value)
+	return nil
+}
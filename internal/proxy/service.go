@@ -5,10 +5,11 @@ import (
 	"compress/gzip"
 	"encoding/base64"
 	"fmt"
+	"go-api-tester/internal/graphql"
 	"io"
-	"mime/multipart"
 	"net"
 	"net/http"
+	"net/http/httptrace"
 	"net/url"
 	"strings"
 	"time"
@@ -16,27 +17,75 @@ import (
 )
 
 // SendRequest 执行实际的 HTTP 请求
+// 流程: 继承认证解析 -> 请求前脚本 -> 变量替换 -> (GraphQL 校验) -> 发送 -> 断言评估 -> 变量提取 -> 响应后脚本
 func SendRequest(req ProxyRequest) ProxyResponse {
-	// 1. URL & Params 处理
-	targetURL := req.URL
-	if !strings.HasPrefix(targetURL, "http://") && !strings.HasPrefix(targetURL, "https://") {
-		targetURL = "http://" + targetURL
+	var (
+		resp ProxyResponse
+		sent *ProxyRequest // 变量全部解析后的请求，未能解析时为 nil
+	)
+	scripts := &scriptRun{}
+	authSource, err := resolveInheritedAuth(&req)
+	if err != nil {
+		resp = ProxyResponse{Error: "Resolve Inherited Auth Failed: " + err.Error()}
+	} else if err := scripts.preRequest(&req); err != nil {
+		resp = ProxyResponse{Error: "Pre-request Script Failed: " + err.Error()}
+	} else if missing, err := substituteVariables(&req); err != nil {
+		resp = ProxyResponse{Error: "Load Variables Failed: " + err.Error()}
+	} else if len(missing) > 0 {
+		resp = ProxyResponse{
+			Error:          "存在未解析的变量 (Unresolved Variables): " + strings.Join(missing, ", "),
+			UnresolvedVars: missing,
+		}
+	} else {
+		resolved := req
+		sent = &resolved
+		if errs, err := graphqlPreflight(req); err != nil || len(errs) > 0 {
+			resp = ProxyResponse{Error: "GraphQL 校验失败 (GraphQL Validation Failed)", GraphQLErrors: errs}
+			if err != nil {
+				resp.Error = "Load GraphQL Schema Failed: " + err.Error()
+			}
+		} else {
+			resp = doRequest(req)
+		}
 	}
-	parsedURL, err := url.Parse(targetURL)
+	resp.AuthSource = authSource
+	resp.sent = sent
+
+	// 断言评估 (请求失败时所有断言均判定为未通过)
+	if len(req.Assertions) > 0 {
+		resp.Assertions = evaluateAssertions(req.Assertions, resp)
+	}
+
+	// 变量提取 (供后续请求串联使用)
+	if len(req.Extractors) > 0 {
+		resp.Extracted = runExtractors(req, resp)
+	}
+
+	// 响应后脚本 (可读取提取器写入的变量)
+	scripts.postResponse(req, &resp)
+	return resp
+}
+
+// graphqlPreflight 发送 GraphQL 请求前按缓存的 schema 校验查询
+func graphqlPreflight(req ProxyRequest) ([]graphql.QueryError, error) {
+	if req.BodyType != "graphql" || req.SkipGraphQLValidation {
+		return nil, nil
+	}
+	return validateBeforeSend(req)
+}
+
+// doRequest 构建并发送 HTTP 请求，读取响应
+func doRequest(req ProxyRequest) ProxyResponse {
+	// 1. URL & Params 处理
+	finalURL, err := buildURL(req)
 	if err != nil {
 		return ProxyResponse{Error: "Invalid URL: " + err.Error()}
 	}
-	query := parsedURL.Query()
-	for _, p := range req.Params {
-		if p.Enabled && p.Key != "" {
-			query.Add(p.Key, p.Value)
-		}
-	}
-	parsedURL.RawQuery = query.Encode()
-	finalURL := parsedURL.String()
 
 	// 2. Body 处理
 	var bodyReader io.Reader
+	var binary *fileSource // binary 类型: 直接以文件流作为请求体
+	boundary := ""         // form-data 类型: 固定分隔符，重放请求体时保持一致
 	contentType := ""
 	switch req.BodyType {
 	case "raw":
@@ -51,21 +100,28 @@ func SendRequest(req ProxyRequest) ProxyResponse {
 		bodyReader = strings.NewReader(data.Encode())
 		contentType = "application/x-www-form-urlencoded"
 	case "form-data":
-		bodyBuf := &bytes.Buffer{}
-		writer := multipart.NewWriter(bodyBuf)
-		for _, item := range req.FormData {
-			if item.Enabled && item.Key != "" {
-				if item.Type == "file" {
-					w, _ := writer.CreateFormFile(item.Key, "test.bin")
-					w.Write([]byte("[File Upload]"))
-				} else {
-					writer.WriteField(item.Key, item.Value)
-				}
-			}
+		boundary = randomHex(30)
+		body, ct, err := multipartBody(req.FormData, boundary)
+		if err != nil {
+			return ProxyResponse{Error: "Open File Failed: " + err.Error()}
+		}
+		bodyReader = body
+		contentType = ct
+	case "binary":
+		src, err := openFileRef(req.BinaryPath)
+		if err != nil {
+			return ProxyResponse{Error: "Open File Failed: " + err.Error()}
 		}
-		writer.Close()
-		bodyReader = bodyBuf
-		contentType = writer.FormDataContentType()
+		binary = src
+		bodyReader = src.file
+		contentType = src.mimeType("")
+	case "graphql":
+		body, err := graphqlBody(req)
+		if err != nil {
+			return ProxyResponse{Error: "Invalid GraphQL Body: " + err.Error()}
+		}
+		bodyReader = bytes.NewReader(body)
+		contentType = "application/json"
 	case "none":
 		bodyReader = nil
 	default:
@@ -75,8 +131,31 @@ func SendRequest(req ProxyRequest) ProxyResponse {
 	// 3. 创建请求
 	goReq, err := http.NewRequest(req.Method, finalURL, bodyReader)
 	if err != nil {
+		if c, ok := bodyReader.(io.Closer); ok {
+			c.Close()
+		}
 		return ProxyResponse{Error: "Create Request Failed: " + err.Error()}
 	}
+	if boundary != "" {
+		// 重定向、Digest 重试与 HMAC 签名需要重新生成请求体
+		items := req.FormData
+		goReq.GetBody = func() (io.ReadCloser, error) {
+			body, _, err := multipartBody(items, boundary)
+			return body, err
+		}
+	}
+	if binary != nil {
+		// 声明长度避免分块传输，307/308 重定向时重新打开文件
+		goReq.ContentLength = binary.size
+		ref := req.BinaryPath
+		goReq.GetBody = func() (io.ReadCloser, error) {
+			src, err := openFileRef(ref)
+			if err != nil {
+				return nil, err
+			}
+			return src.file, nil
+		}
+	}
 	if contentType != "" {
 		goReq.Header.Set("Content-Type", contentType)
 	}
@@ -85,28 +164,44 @@ func SendRequest(req ProxyRequest) ProxyResponse {
 			goReq.Header.Set(h.Key, h.Value)
 		}
 	}
-	
+
 	// 移除 Accept-Encoding，让 Transport 自动处理 Content-Encoding: gzip
 	// 但如果服务器返回的是 application/x-gzip 文件流，Transport 不会解压，需要我们在 readResponseBody 处理
 	goReq.Header.Del("Accept-Encoding")
 
-	// 4. Auth
-	switch req.Auth.Type {
-	case "basic":
-		auth := req.Auth.Basic["username"] + ":" + req.Auth.Basic["password"]
-		goReq.Header.Set("Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(auth)))
-	case "bearer":
-		goReq.Header.Set("Authorization", "Bearer "+req.Auth.Bearer["token"])
+	// 4. Auth (OAuth 2.0 在此取得令牌，即将过期时自动刷新)
+	authErr := applyAuth(goReq, req.Auth)
+	if authErr == nil && req.Auth.Type == AuthOAuth2 {
+		authErr = applyOAuth2(goReq, req)
+	}
+	if err := authErr; err != nil {
+		if goReq.Body != nil {
+			goReq.Body.Close()
+		}
+		return ProxyResponse{Error: "Auth Failed: " + err.Error()}
 	}
 
 	// 5. 发送
-	client := &http.Client{Timeout: 60 * time.Second}
+	settings, err := effectiveSettings(req)
+	if err != nil {
+		return ProxyResponse{Error: "Load Settings Failed: " + err.Error()}
+	}
+	redirects := &redirectRecorder{}
+	client, err := buildClient(req, goReq.URL, settings, redirects)
+	if err != nil {
+		return ProxyResponse{Error: "Create Client Failed: " + err.Error()}
+	}
+	timing := newTimingRecorder()
+	goReq = goReq.WithContext(httptrace.WithClientTrace(goReq.Context(), timing.trace()))
 	startTime := time.Now()
 	resp, err := client.Do(goReq)
 	duration := time.Since(startTime)
 
 	if err != nil {
-		return handleError(err, duration)
+		errResp := handleError(err, duration)
+		errResp.Redirects = redirects.hops
+		errResp.Timing = timing.result(time.Now())
+		return errResp
 	}
 	defer resp.Body.Close()
 
@@ -117,6 +212,8 @@ func SendRequest(req ProxyRequest) ProxyResponse {
 			StatusCode: resp.StatusCode,
 			TimeMs:     duration.Milliseconds(),
 			Error:      fmt.Sprintf("Read Body Failed: %v", readErr),
+			Redirects:  redirects.hops,
+			Timing:     timing.result(time.Now()),
 		}
 	}
 
@@ -126,9 +223,32 @@ func SendRequest(req ProxyRequest) ProxyResponse {
 		Body:       bodyStr,
 		IsBinary:   isBinary, // 前端根据此字段决定是否显示 Hex/Image 视图
 		TimeMs:     duration.Milliseconds(),
+		Protocol:   resp.Proto,
+		Redirects:  redirects.hops,
+		Timing:     timing.result(time.Now()),
 	}
 }
 
+// buildURL 补全协议并合并启用的查询参数，得到实际请求的 URL
+func buildURL(req ProxyRequest) (string, error) {
+	targetURL := req.URL
+	if !strings.HasPrefix(targetURL, "http://") && !strings.HasPrefix(targetURL, "https://") {
+		targetURL = "http://" + targetURL
+	}
+	parsedURL, err := url.Parse(targetURL)
+	if err != nil {
+		return "", err
+	}
+	query := parsedURL.Query()
+	for _, p := range req.Params {
+		if p.Enabled && p.Key != "" {
+			query.Add(p.Key, p.Value)
+		}
+	}
+	parsedURL.RawQuery = query.Encode()
+	return parsedURL.String(), nil
+}
+
 // readAndProcessBody 读取 Body，尝试解压，并判断是否为文本
 func readAndProcessBody(resp *http.Response) (string, bool, error) {
 	// 1. 读取所有原始字节
@@ -208,4 +328,4 @@ func handleError(err error, duration time.Duration) ProxyResponse {
 	}
 	resp.Error = fmt.Sprintf("网络请求错误: %v", err)
 	return resp
-}
\ No newline at end of file
+}
@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timing 请求各阶段耗时 (毫秒)
+// DNS、TCP 连接与 TLS 握手为最近一次新建连接的耗时 (未新建连接时为 0)，其余阶段为最后一跳 (重定向后) 的响应
+type Timing struct {
+	DNSMs      float64 `json:"dns_ms"`
+	ConnectMs  float64 `json:"connect_ms"`
+	TLSMs      float64 `json:"tls_ms"`
+	TTFBMs     float64 `json:"ttfb_ms"`     // 请求发送完成到收到响应首字节
+	TransferMs float64 `json:"transfer_ms"` // 首字节到响应体读取完成
+	TotalMs    float64 `json:"total_ms"`    // 开始发送到响应体读取完成 (包含重定向)
+	RemoteAddr string  `json:"remote_addr,omitempty"`
+	Reused     bool    `json:"reused"` // 是否复用了已有连接
+}
+
+// timingRecorder 通过 httptrace 记录各阶段的时间点，回调可能来自其他 goroutine
+type timingRecorder struct {
+	mu sync.Mutex
+
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	gotConn      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+
+	remoteAddr string
+	reused     bool
+	connecting bool // 本跳已开始新建连接
+}
+
+func newTimingRecorder() *timingRecorder {
+	return &timingRecorder{start: time.Now()}
+}
+
+// trace 返回记录时间点的 ClientTrace
+func (t *timingRecorder) trace() *httptrace.ClientTrace {
+	mark := func(f func()) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		f()
+	}
+	return &httptrace.ClientTrace{
+		GetConn: func(string) {
+			// 每一跳 (重定向、Digest 重试) 重新记录响应阶段
+			mark(func() {
+				t.gotConn, t.wroteRequest, t.firstByte = time.Time{}, time.Time{}, time.Time{}
+				t.connecting = false
+			})
+		},
+		DNSStart: func(httptrace.DNSStartInfo) {
+			mark(func() {
+				t.newConnection()
+				t.dnsStart = time.Now()
+			})
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) { mark(func() { t.dnsDone = time.Now() }) },
+		ConnectStart: func(string, string) {
+			// 同时尝试多个地址 (Happy Eyeballs) 时取最早的开始时间
+			mark(func() {
+				t.newConnection()
+				if t.connectStart.IsZero() {
+					t.connectStart = time.Now()
+				}
+			})
+		},
+		ConnectDone: func(_, _ string, err error) {
+			mark(func() {
+				if err == nil {
+					t.connectDone = time.Now()
+				}
+			})
+		},
+		TLSHandshakeStart: func() { mark(func() { t.tlsStart = time.Now() }) },
+		TLSHandshakeDone:  func(tls.ConnectionState, error) { mark(func() { t.tlsDone = time.Now() }) },
+		GotConn: func(info httptrace.GotConnInfo) {
+			mark(func() {
+				t.gotConn = time.Now()
+				t.reused = info.Reused
+				if info.Conn != nil {
+					t.remoteAddr = info.Conn.RemoteAddr().String()
+				}
+			})
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { mark(func() { t.wroteRequest = time.Now() }) },
+		GotFirstResponseByte: func() { mark(func() { t.firstByte = time.Now() }) },
+	}
+}
+
+// newConnection 本跳第一次进入建连阶段时清除上一次连接的记录
+func (t *timingRecorder) newConnection() {
+	if t.connecting {
+		return
+	}
+	t.connecting = true
+	t.dnsStart, t.dnsDone = time.Time{}, time.Time{}
+	t.connectStart, t.connectDone = time.Time{}, time.Time{}
+	t.tlsStart, t.tlsDone = time.Time{}, time.Time{}
+}
+
+// result 汇总各阶段耗时，end 为响应体读取完成 (或请求失败) 的时间
+func (t *timingRecorder) result(end time.Time) *Timing {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timing := &Timing{
+		DNSMs:      durationMs(t.dnsStart, t.dnsDone),
+		ConnectMs:  durationMs(t.connectStart, t.connectDone),
+		TLSMs:      durationMs(t.tlsStart, t.tlsDone),
+		TotalMs:    durationMs(t.start, end),
+		RemoteAddr: t.remoteAddr,
+		Reused:     t.reused,
+	}
+	sent := t.wroteRequest
+	if sent.IsZero() {
+		sent = t.gotConn
+	}
+	timing.TTFBMs = durationMs(sent, t.firstByte)
+	timing.TransferMs = durationMs(t.firstByte, end)
+	return timing
+}
+
+// durationMs 两个时间点之间的毫秒数，任一时间点未记录时为 0
+func durationMs(from, to time.Time) float64 {
+	if from.IsZero() || to.IsZero() || to.Before(from) {
+		return 0
+	}
+	return float64(to.Sub(from).Microseconds()) / 1000
+}
@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"go-api-tester/internal/database"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDurationMs(t *testing.T) {
+	base := time.Now()
+	tests := []struct {
+		from, to time.Time
+		want     float64
+	}{
+		{base, base.Add(1500 * time.Microsecond), 1.5},
+		{base, base.Add(2 * time.Second), 2000},
+		{time.Time{}, base, 0},
+		{base, time.Time{}, 0},
+		{base, base.Add(-time.Millisecond), 0},
+	}
+	for _, tt := range tests {
+		if got := durationMs(tt.from, tt.to); got != tt.want {
+			t.Errorf("durationMs(%v) = %v, want %v", tt.to.Sub(tt.from), got, tt.want)
+		}
+	}
+}
+
+func TestRequestTiming(t *testing.T) {
+	const delay = 50 * time.Millisecond
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.Write([]byte("ok"))
+	}))
+	srv.Config.ErrorLog = log.New(io.Discard, "", 0)
+	srv.StartTLS()
+	defer srv.Close()
+
+	skip := true
+	req := ProxyRequest{Method: "GET", URL: srv.URL, Settings: database.ClientSettings{SkipTLSVerify: &skip, HTTPVersion: "1.1"}}
+
+	first := doRequest(req)
+	if first.Error != "" {
+		t.Fatal(first.Error)
+	}
+	tm := first.Timing
+	if tm == nil {
+		t.Fatal("timing missing")
+	}
+	if tm.Reused || tm.TLSMs <= 0 || tm.DNSMs != 0 || tm.RemoteAddr != srv.Listener.Addr().String() {
+		t.Errorf("first request timing = %+v", tm)
+	}
+	if tm.TTFBMs < float64(delay.Milliseconds()) || tm.TotalMs < tm.ConnectMs+tm.TLSMs+tm.TTFBMs {
+		t.Errorf("first request phases = %+v", tm)
+	}
+
+	// 第二次请求复用连接，不再有建连与握手耗时
+	second := doRequest(req)
+	if tm := second.Timing; tm == nil || !tm.Reused || tm.ConnectMs != 0 || tm.TLSMs != 0 || tm.TTFBMs < float64(delay.Milliseconds()) {
+		t.Errorf("second request timing = %+v", tm)
+	}
+}
+
+func TestRequestTimingOnError(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	url := srv.URL
+	srv.Close()
+
+	resp := doRequest(ProxyRequest{Method: "GET", URL: url})
+	if resp.Error == "" || resp.Timing == nil || resp.Timing.TTFBMs != 0 {
+		t.Errorf("failed request = %+v, timing %+v", resp, resp.Timing)
+	}
+}
@@ -0,0 +1,155 @@
+package proxy
+
+import (
+	"fmt"
+	"go-api-tester/internal/database"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// AttachmentRefPrefix 文件引用前缀: "attachment:12" 表示附件库中 ID 为 12 的文件
+// 不带前缀的值视为本地文件路径
+const AttachmentRefPrefix = "attachment:"
+
+// fileSource 已打开的待上传文件
+type fileSource struct {
+	file        *os.File
+	name        string // 上传时使用的文件名
+	contentType string
+	size        int64
+}
+
+// openFileRef 打开文件引用 (附件或本地路径)
+func openFileRef(ref string) (*fileSource, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("未选择文件")
+	}
+
+	if idStr, ok := strings.CutPrefix(ref, AttachmentRefPrefix); ok {
+		id, err := strconv.ParseInt(idStr, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的附件引用: %s", ref)
+		}
+		f, a, err := database.OpenAttachment(id)
+		if err != nil {
+			return nil, fmt.Errorf("附件 %d 不可用: %v", id, err)
+		}
+		return &fileSource{file: f, name: a.Name, contentType: a.ContentType, size: a.Size}, nil
+	}
+
+	f, err := os.Open(ref)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, fmt.Errorf("%s 是目录", ref)
+	}
+	return &fileSource{file: f, name: filepath.Base(ref), size: info.Size()}, nil
+}
+
+// mimeType 返回文件的 Content-Type: 显式指定 > 附件记录 > 扩展名推断 > application/octet-stream
+func (s *fileSource) mimeType(override string) string {
+	if override != "" {
+		return override
+	}
+	if s.contentType != "" {
+		return s.contentType
+	}
+	if t := mime.TypeByExtension(filepath.Ext(s.name)); t != "" {
+		return t
+	}
+	return "application/octet-stream"
+}
+
+// multipartBody 以流的方式生成 multipart/form-data 请求体，boundary 为空时随机生成
+// 所有文件在开始发送前打开，文件不存在等错误会直接返回而不是发送半截请求
+func multipartBody(items []KeyValue, boundary string) (io.ReadCloser, string, error) {
+	files := make(map[int]*fileSource)
+	closeAll := func() {
+		for _, f := range files {
+			f.file.Close()
+		}
+	}
+	for i, item := range items {
+		if item.Enabled && item.Key != "" && item.Type == "file" {
+			src, err := openFileRef(item.Value)
+			if err != nil {
+				closeAll()
+				return nil, "", fmt.Errorf("字段 %s: %v", item.Key, err)
+			}
+			files[i] = src
+		}
+	}
+
+	pr, pw := io.Pipe()
+	writer := multipart.NewWriter(pw)
+	if boundary != "" {
+		if err := writer.SetBoundary(boundary); err != nil {
+			closeAll()
+			return nil, "", err
+		}
+	}
+	go func() {
+		defer closeAll()
+		err := writeMultipart(writer, items, files)
+		if err == nil {
+			err = writer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, writer.FormDataContentType(), nil
+}
+
+func writeMultipart(writer *multipart.Writer, items []KeyValue, files map[int]*fileSource) error {
+	for i, item := range items {
+		if !item.Enabled || item.Key == "" {
+			continue
+		}
+
+		h := make(textproto.MIMEHeader)
+		if src, ok := files[i]; ok {
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, escapeQuotes(item.Key), escapeQuotes(src.name)))
+			h.Set("Content-Type", src.mimeType(item.ContentType))
+			part, err := writer.CreatePart(h)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(part, src.file); err != nil {
+				return err
+			}
+			continue
+		}
+
+		h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, escapeQuotes(item.Key)))
+		if item.ContentType != "" {
+			h.Set("Content-Type", item.ContentType)
+		}
+		part, err := writer.CreatePart(h)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(part, item.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+func escapeQuotes(s string) string {
+	return quoteEscaper.Replace(s)
+}
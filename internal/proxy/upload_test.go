@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"fmt"
+	"go-api-tester/internal/database"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// uploadServer 第一次请求返回 307 重定向，重定向后解析请求体并回显
+func uploadServer(t *testing.T, echo func(w http.ResponseWriter, r *http.Request)) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/upload" {
+			io.Copy(io.Discard, r.Body)
+			http.Redirect(w, r, "/final", http.StatusTemporaryRedirect)
+			return
+		}
+		echo(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMultipartUpload(t *testing.T) {
+	openTestDB(t)
+	local := filepath.Join(t.TempDir(), "data.json")
+	if err := os.WriteFile(local, []byte(`{"a":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	att, err := database.CreateAttachment(`photo "1".png`, "image/png", strings.NewReader("PNGDATA"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := uploadServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseMultipartForm(1 << 20); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		var lines []string
+		for _, k := range []string{"title", "disabled"} {
+			lines = append(lines, fmt.Sprintf("%s=%q", k, r.MultipartForm.Value[k]))
+		}
+		for _, k := range []string{"doc", "photo"} {
+			for _, fh := range r.MultipartForm.File[k] {
+				f, _ := fh.Open()
+				b, _ := io.ReadAll(f)
+				f.Close()
+				lines = append(lines, fmt.Sprintf("%s=%s|%s|%s", k, fh.Filename, fh.Header.Get("Content-Type"), b))
+			}
+		}
+		w.Write([]byte(strings.Join(lines, "\n")))
+	})
+
+	resp := doRequest(ProxyRequest{
+		Method:   "POST",
+		URL:      srv.URL + "/upload",
+		BodyType: "form-data",
+		FormData: []KeyValue{
+			{Key: "title", Value: "report", Enabled: true},
+			{Key: "disabled", Value: "x", Enabled: false},
+			{Key: "doc", Value: local, Type: "file", Enabled: true},
+			{Key: "photo", Value: fmt.Sprintf("%s%d", AttachmentRefPrefix, att.ID), Type: "file", Enabled: true},
+		},
+	})
+	if resp.Error != "" {
+		t.Fatalf("upload failed: %s", resp.Error)
+	}
+	want := strings.Join([]string{
+		`title=["report"]`,
+		`disabled=[]`,
+		`doc=data.json|application/json|{"a":1}`,
+		`photo=photo "1".png|image/png|PNGDATA`,
+	}, "\n")
+	// 307 重定向后请求体完整重放
+	if resp.StatusCode != 200 || resp.Body != want {
+		t.Errorf("response = %d\n%s\nwant\n%s", resp.StatusCode, resp.Body, want)
+	}
+
+	resp = doRequest(ProxyRequest{Method: "POST", URL: srv.URL + "/final", BodyType: "form-data",
+		FormData: []KeyValue{{Key: "doc", Value: filepath.Join(t.TempDir(), "missing.txt"), Type: "file", Enabled: true}}})
+	if !strings.Contains(resp.Error, "Open File Failed") {
+		t.Errorf("missing file: error = %q", resp.Error)
+	}
+}
+
+func TestBinaryUpload(t *testing.T) {
+	openTestDB(t)
+	content := strings.Repeat("binary-data-", 1000)
+	local := filepath.Join(t.TempDir(), "blob.noext")
+	if err := os.WriteFile(local, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	srv := uploadServer(t, func(w http.ResponseWriter, r *http.Request) {
+		b, _ := io.ReadAll(r.Body)
+		fmt.Fprintf(w, "%s|%d|%v|%t", r.Header.Get("Content-Type"), r.ContentLength, r.TransferEncoding, string(b) == content)
+	})
+
+	resp := doRequest(ProxyRequest{Method: "PUT", URL: srv.URL + "/upload", BodyType: "binary", BinaryPath: local})
+	if resp.Error != "" {
+		t.Fatalf("upload failed: %s", resp.Error)
+	}
+	if want := fmt.Sprintf("application/octet-stream|%d|[]|true", len(content)); resp.Body != want {
+		t.Errorf("response = %q, want %q", resp.Body, want)
+	}
+
+	resp = doRequest(ProxyRequest{Method: "PUT", URL: srv.URL + "/final", BodyType: "binary", BinaryPath: AttachmentRefPrefix + "999"})
+	if !strings.Contains(resp.Error, "Open File Failed") {
+		t.Errorf("missing attachment: error = %q", resp.Error)
+	}
+}
@@ -0,0 +1,131 @@
+package proxy
+
+import (
+	"fmt"
+	"go-api-tester/internal/database"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// resolveProxySettings 返回请求使用的上游代理配置: 环境配置优先，其次全局配置
+func resolveProxySettings(req ProxyRequest) (database.ProxySettings, error) {
+	if database.DB == nil {
+		return database.ProxySettings{}, nil
+	}
+	env, err := loadEnvironment(req.EnvironmentID)
+	if err != nil {
+		return database.ProxySettings{}, err
+	}
+	if env != nil && env.Proxy != nil && env.Proxy.Mode != "" {
+		return *env.Proxy, nil
+	}
+	return database.GetProxySettings()
+}
+
+// ValidateProxySettings 检查代理配置是否有效 (保存前校验)
+func ValidateProxySettings(s database.ProxySettings) error {
+	_, err := proxyFunc(s)
+	return err
+}
+
+// proxyFunc 根据代理配置构建 http.Transport.Proxy，直连时返回 nil
+// http/https 代理对 HTTPS 目标使用 CONNECT 隧道，socks5 由 Transport 内置支持
+func proxyFunc(s database.ProxySettings) (func(*http.Request) (*url.URL, error), error) {
+	switch s.Mode {
+	case "", database.ProxyModeSystem:
+		return http.ProxyFromEnvironment, nil
+	case database.ProxyModeNone:
+		return nil, nil
+	case database.ProxyModeCustom:
+	default:
+		return nil, fmt.Errorf("不支持的代理模式: %s", s.Mode)
+	}
+
+	proxyURL, err := url.Parse(s.URL)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("代理地址无效: %s", s.URL)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("不支持的代理协议: %s (可选 http、https、socks5)", proxyURL.Scheme)
+	}
+	if s.Username != "" {
+		proxyURL.User = url.UserPassword(s.Username, s.Password)
+	}
+
+	bypass := s.Bypass
+	return func(r *http.Request) (*url.URL, error) {
+		if bypassProxy(bypass, r.URL) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}, nil
+}
+
+// proxyFingerprint 代理配置摘要，用于区分 Transport 缓存
+func proxyFingerprint(s database.ProxySettings) string {
+	if s.Mode == "" {
+		return database.ProxyModeSystem
+	}
+	return strings.Join([]string{s.Mode, s.URL, s.Username, s.Password, strings.Join(s.Bypass, ",")}, "\x00")
+}
+
+// bypassProxy 判断目标地址是否命中直连列表
+// 规则: * 全部直连; <local> 不含点的主机名与回环地址; CIDR 匹配 IP;
+// *.example.com / .example.com 只匹配子域名; example.com 匹配自身及子域名; 可带 :port 限定端口
+func bypassProxy(rules []string, u *url.URL) bool {
+	host := canonicalHost(u)
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if u.Scheme == "http" {
+			port = "80"
+		}
+	}
+	ip := net.ParseIP(host)
+
+	for _, rule := range rules {
+		rule = strings.ToLower(strings.TrimSpace(rule))
+		if rule == "" {
+			continue
+		}
+		if rule == "*" {
+			return true
+		}
+		if rule == "<local>" {
+			if !strings.Contains(host, ".") || host == "localhost" || (ip != nil && ip.IsLoopback()) {
+				return true
+			}
+			continue
+		}
+		if _, network, err := net.ParseCIDR(rule); err == nil {
+			if ip != nil && network.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		if h, p, err := net.SplitHostPort(rule); err == nil {
+			if p != port {
+				continue
+			}
+			rule = h
+		}
+		switch {
+		case strings.HasPrefix(rule, "*."):
+			if strings.HasSuffix(host, rule[1:]) {
+				return true
+			}
+		case strings.HasPrefix(rule, "."):
+			if strings.HasSuffix(host, rule) {
+				return true
+			}
+		case host == rule || strings.HasSuffix(host, "."+rule):
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,217 @@
+package proxy
+
+import (
+	"database/sql"
+	"fmt"
+	"go-api-tester/internal/database"
+	"regexp"
+	"sort"
+)
+
+// varPattern 匹配 {{name}} 形式的占位符，允许两侧留空格
+var varPattern = regexp.MustCompile(`\{\{\s*([\w.\-$]+)\s*\}\}`)
+
+// maxResolveDepth 变量值中允许继续引用其他变量的最大层数，防止循环引用
+const maxResolveDepth = 10
+
+// variableResolver 负责占位符替换，并记录所有未能解析的变量名
+type variableResolver struct {
+	vars       map[string]string
+	unresolved map[string]bool
+}
+
+func newVariableResolver(vars map[string]string) *variableResolver {
+	return &variableResolver{
+		vars:       vars,
+		unresolved: make(map[string]bool),
+	}
+}
+
+// replace 替换字符串中的所有占位符
+func (v *variableResolver) replace(s string) string {
+	return v.replaceDepth(s, 0)
+}
+
+func (v *variableResolver) replaceDepth(s string, depth int) string {
+	return varPattern.ReplaceAllStringFunc(s, func(m string) string {
+		name := varPattern.FindStringSubmatch(m)[1]
+		val, ok := v.vars[name]
+		if !ok || depth >= maxResolveDepth {
+			v.unresolved[name] = true
+			return m
+		}
+		return v.replaceDepth(val, depth+1)
+	})
+}
+
+func (v *variableResolver) replaceKV(list []KeyValue) []KeyValue {
+	if list == nil {
+		return nil
+	}
+	out := make([]KeyValue, len(list))
+	for i, kv := range list {
+		out[i] = kv
+		if !kv.Enabled {
+			continue
+		}
+		out[i].Key = v.replace(kv.Key)
+		out[i].Value = v.replace(kv.Value)
+	}
+	return out
+}
+
+func (v *variableResolver) replaceMap(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, val := range m {
+		out[k] = v.replace(val)
+	}
+	return out
+}
+
+// apply 对请求的 URL、Params、Headers、Auth 与各类 Body 执行替换
+func (v *variableResolver) apply(req *ProxyRequest) {
+	req.URL = v.replace(req.URL)
+	req.Params = v.replaceKV(req.Params)
+	req.Headers = v.replaceKV(req.Headers)
+
+	// 只替换当前认证类型使用的配置
+	switch req.Auth.Type {
+	case AuthBasic:
+		req.Auth.Basic = v.replaceMap(req.Auth.Basic)
+	case AuthBearer:
+		req.Auth.Bearer = v.replaceMap(req.Auth.Bearer)
+	case AuthAPIKey:
+		req.Auth.APIKey = v.replaceMap(req.Auth.APIKey)
+	case AuthDigest:
+		req.Auth.Digest = v.replaceMap(req.Auth.Digest)
+	case AuthHMAC:
+		req.Auth.HMAC = v.replaceMap(req.Auth.HMAC)
+	case AuthOAuth2:
+		req.Auth.OAuth2 = v.replaceMap(req.Auth.OAuth2)
+	case AuthAWSV4:
+		req.Auth.AWSV4 = v.replaceMap(req.Auth.AWSV4)
+	}
+
+	if req.Assertions != nil {
+		assertions := make([]database.Assertion, len(req.Assertions))
+		for i, a := range req.Assertions {
+			assertions[i] = a
+			if a.Enabled {
+				assertions[i].Target = v.replace(a.Target)
+				assertions[i].Expected = v.replace(a.Expected)
+			}
+		}
+		req.Assertions = assertions
+	}
+
+	// 只替换实际会被发送的 Body，避免未使用的 Body 类型误报未解析变量
+	switch req.BodyType {
+	case "none":
+	case "x-www-form-urlencoded":
+		req.UrlEncoded = v.replaceKV(req.UrlEncoded)
+	case "form-data":
+		req.FormData = v.replaceKV(req.FormData)
+	case "binary":
+		req.BinaryPath = v.replace(req.BinaryPath)
+	case "graphql":
+		req.GraphQLQuery = v.replace(req.GraphQLQuery)
+		req.GraphQLVars = v.replace(req.GraphQLVars)
+	default:
+		req.RawBody = v.replace(req.RawBody)
+	}
+}
+
+// substituteVariables 加载变量作用域并就地替换请求中的占位符，返回未解析的变量名
+func substituteVariables(req *ProxyRequest) ([]string, error) {
+	vars, err := loadVariables(*req)
+	if err != nil {
+		return nil, err
+	}
+	resolver := newVariableResolver(vars)
+	resolver.apply(req)
+	return resolver.unresolvedList(), nil
+}
+
+// unresolvedList 返回排序后的未解析变量名
+func (v *variableResolver) unresolvedList() []string {
+	if len(v.unresolved) == 0 {
+		return nil
+	}
+	list := make([]string, 0, len(v.unresolved))
+	for name := range v.unresolved {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// loadVariables 按作用域优先级合并请求可见的变量
+// 合并顺序 (后者覆盖前者): 全局 -> 环境 -> 分组链 (根到叶) -> 请求 -> 运行时
+func loadVariables(req ProxyRequest) (map[string]string, error) {
+	vars := make(map[string]string)
+	merge := func(m map[string]string) {
+		for k, v := range m {
+			vars[k] = v
+		}
+	}
+
+	if database.DB != nil {
+		// 1. 全局变量
+		globals, err := database.GetGlobalVariables()
+		if err != nil {
+			return nil, err
+		}
+		merge(database.VariableMap(globals))
+
+		// 2. 环境变量: EnvironmentID 非 0 时使用指定环境，否则使用当前激活的环境
+		env, err := loadEnvironment(req.EnvironmentID)
+		if err != nil {
+			return nil, err
+		}
+		if env != nil {
+			merge(env.VariableMap())
+		}
+
+		// 3. 分组变量: 沿 ParentID 链从根节点到所在分组，越深的分组优先级越高
+		if req.CollectionID != 0 {
+			path, err := database.GetCollectionPath(req.CollectionID)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range path {
+				merge(database.VariableMap(c.Variables))
+			}
+		}
+	}
+
+	// 4. 请求级变量
+	for _, kv := range req.Variables {
+		if kv.Enabled && kv.Key != "" {
+			vars[kv.Key] = kv.Value
+		}
+	}
+
+	// 5. 运行时变量 (提取器写入)
+	merge(req.runtime().Snapshot())
+	return vars, nil
+}
+
+// loadEnvironment 加载指定环境，id 为 0 时返回当前激活的环境 (可能为 nil)
+func loadEnvironment(id int64) (*database.Environment, error) {
+	if id != 0 {
+		env, err := database.GetEnvironment(id)
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("environment %d not found", id)
+		}
+		return env, err
+	}
+	env, err := database.GetActiveEnvironment()
+	if err == sql.ErrNoRows {
+		// 没有激活的环境，视为空作用域
+		return nil, nil
+	}
+	return env, err
+}
@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteText 输出便于人阅读的文本报告
+func WriteText(w io.Writer, r *Report) error {
+	for _, res := range r.Results {
+		mark := "✔"
+		if !res.Passed {
+			mark = "✘"
+		}
+		name := res.Name
+		if res.Folder != "" {
+			name = res.Folder + "/" + res.Name
+		}
+		prefix := ""
+		if r.Iterations > 1 {
+			prefix = fmt.Sprintf("[%d] ", res.Iteration)
+		}
+		fmt.Fprintf(w, "%s %s%s  %s %s  -> %d (%d ms)\n", mark, prefix, name, res.Method, res.URL, res.Status, res.TimeMs)
+
+		if res.Error != "" {
+			fmt.Fprintf(w, "    错误: %s\n", res.Error)
+		}
+		for _, a := range res.Assertions {
+			if a.Passed {
+				fmt.Fprintf(w, "    ✔ %s %s %s\n", a.Type, a.Target, a.Expected)
+			} else {
+				fmt.Fprintf(w, "    ✘ %s %s %s: %s\n", a.Type, a.Target, a.Expected, a.Message)
+			}
+		}
+	}
+
+	fmt.Fprintf(w, "\n%s: 请求 %d (通过 %d / 失败 %d)，断言 %d (失败 %d)，耗时 %d ms\n",
+		r.Name, r.Total, r.Passed, r.Failed, r.Assertions, r.AssertFails, r.TotalTimeMs)
+	if r.Aborted {
+		fmt.Fprintln(w, "因失败提前停止 (stop on failure)")
+	}
+	return nil
+}
+
+// WriteJSON 输出 JSON 报告
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// JUnit XML 结构
+type junitSuites struct {
+	XMLName xml.Name     `xml:"testsuites"`
+	Name    string       `xml:"name,attr"`
+	Tests   int          `xml:"tests,attr"`
+	Failure int          `xml:"failures,attr"`
+	Time    string       `xml:"time,attr"`
+	Suites  []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name      string      `xml:"name,attr"`
+	Tests     int         `xml:"tests,attr"`
+	Failures  int         `xml:"failures,attr"`
+	Time      string      `xml:"time,attr"`
+	Timestamp string      `xml:"timestamp,attr"`
+	Cases     []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// WriteJUnit 输出 JUnit XML 报告，每个分组对应一个 testsuite，每次请求执行对应一个 testcase
+func WriteJUnit(w io.Writer, r *Report) error {
+	suites := junitSuites{
+		Name:    r.Name,
+		Tests:   r.Total,
+		Failure: r.Failed,
+		Time:    seconds(r.TotalTimeMs),
+	}
+
+	index := make(map[string]int)
+	var suiteMs []int64
+	for _, res := range r.Results {
+		folder := res.Folder
+		if folder == "" {
+			folder = r.Name
+		}
+		i, ok := index[folder]
+		if !ok {
+			i = len(suites.Suites)
+			index[folder] = i
+			suites.Suites = append(suites.Suites, junitSuite{
+				Name:      folder,
+				Timestamp: r.StartedAt.Format("2006-01-02T15:04:05"),
+			})
+			suiteMs = append(suiteMs, 0)
+		}
+		suite := &suites.Suites[i]
+
+		name := res.Name
+		if r.Iterations > 1 {
+			name = fmt.Sprintf("%s [%d]", res.Name, res.Iteration)
+		}
+		tc := junitCase{
+			Name:      name,
+			ClassName: strings.ReplaceAll(folder, "/", "."),
+			Time:      seconds(res.TimeMs),
+		}
+		if !res.Passed {
+			tc.Failure = junitFailureOf(res)
+			suite.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+		suiteMs[i] += res.TimeMs
+		suite.Time = seconds(suiteMs[i])
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(suites); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+func junitFailureOf(res *RequestResult) *junitFailure {
+	if res.Error != "" {
+		return &junitFailure{Message: res.Error, Type: "error", Text: res.Error}
+	}
+	var lines []string
+	for _, a := range res.Assertions {
+		if !a.Passed {
+			lines = append(lines, fmt.Sprintf("%s %s %s: %s", a.Type, a.Target, a.Expected, a.Message))
+		}
+	}
+	msg := fmt.Sprintf("%d assertion(s) failed", len(lines))
+	return &junitFailure{Message: msg, Type: "assertion", Text: strings.Join(lines, "\n")}
+}
+
+func seconds(ms int64) string {
+	return fmt.Sprintf("%.3f", float64(ms)/1000)
+}
@@ -0,0 +1,210 @@
+// Package runner 按顺序执行分组 (含子分组) 或单个已保存的请求，并汇总断言结果
+package runner
+
+import (
+	"database/sql"
+	"go-api-tester/internal/database"
+	"go-api-tester/internal/proxy"
+	"strings"
+	"time"
+)
+
+// Options 运行选项
+type Options struct {
+	StopOnFailure bool  `json:"stop_on_failure"` // 任一请求失败后立即停止
+	DelayMs       int   `json:"delay_ms"`        // 相邻两个请求之间的等待时间
+	Iterations    int   `json:"iterations"`      // 整个序列的重复次数，<= 0 视为 1
+	EnvironmentID int64 `json:"environment_id"`  // 0 表示使用当前激活的环境
+}
+
+// RequestResult 单个请求在某一轮中的执行结果
+type RequestResult struct {
+	Iteration  int                     `json:"iteration"`
+	RequestID  int64                   `json:"request_id"`
+	Name       string                  `json:"name"`
+	Folder     string                  `json:"folder"` // 所在分组路径，例如 payments/v2
+	Method     string                  `json:"method"`
+	URL        string                  `json:"url"`
+	Status     int                     `json:"status"`
+	TimeMs     int64                   `json:"time_ms"`
+	Error      string                  `json:"error,omitempty"`
+	Assertions []proxy.AssertionResult `json:"assertions,omitempty"`
+	Extracted  []proxy.ExtractResult   `json:"extracted,omitempty"`
+	Passed     bool                    `json:"passed"`
+}
+
+// Report 运行报告
+type Report struct {
+	Name        string           `json:"name"`
+	Iterations  int              `json:"iterations"`
+	Total       int              `json:"total"`  // 已执行的请求数
+	Passed      int              `json:"passed"` // 通过的请求数
+	Failed      int              `json:"failed"` // 失败的请求数
+	Assertions  int              `json:"assertions"`
+	AssertFails int              `json:"assertion_failures"`
+	Aborted     bool             `json:"aborted"` // 因 StopOnFailure 提前结束
+	StartedAt   time.Time        `json:"started_at"`
+	TotalTimeMs int64            `json:"total_time_ms"`
+	Results     []*RequestResult `json:"results"`
+}
+
+// Success 是否全部通过
+func (r *Report) Success() bool {
+	return r.Failed == 0 && !r.Aborted
+}
+
+// runItem 待执行的请求及其所在分组路径
+type runItem struct {
+	req    *database.Request
+	folder string
+}
+
+// RunCollection 运行分组及其所有子分组中的请求
+// 执行顺序: 先执行当前分组的直属请求，再按 ID 顺序深度优先进入子分组
+func RunCollection(id int64, opts Options) (*Report, error) {
+	path, err := database.GetCollectionPath(id)
+	if err != nil {
+		return nil, err
+	}
+	if len(path) == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	names := make([]string, len(path))
+	for i, c := range path {
+		names[i] = c.Name
+	}
+	target := path[len(path)-1]
+
+	items, err := collectItems(target, strings.Join(names, "/"))
+	if err != nil {
+		return nil, err
+	}
+	return run(target.Name, items, opts), nil
+}
+
+// RunRequest 运行单个已保存的请求
+func RunRequest(id int64, opts Options) (*Report, error) {
+	req, err := database.GetRequest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := ""
+	if req.CollectionID != 0 {
+		path, err := database.GetCollectionPath(req.CollectionID)
+		if err != nil {
+			return nil, err
+		}
+		names := make([]string, len(path))
+		for i, c := range path {
+			names[i] = c.Name
+		}
+		folder = strings.Join(names, "/")
+	}
+	return run(req.Name, []runItem{{req: req, folder: folder}}, opts), nil
+}
+
+func collectItems(c *database.Collection, folder string) ([]runItem, error) {
+	reqs, err := database.GetRequestsByCollection(c.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var items []runItem
+	for _, r := range reqs {
+		items = append(items, runItem{req: r, folder: folder})
+	}
+	for _, child := range c.Children {
+		sub, err := collectItems(child, folder+"/"+child.Name)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, sub...)
+	}
+	return items, nil
+}
+
+func run(name string, items []runItem, opts Options) *Report {
+	iterations := opts.Iterations
+	if iterations <= 0 {
+		iterations = 1
+	}
+	delay := time.Duration(opts.DelayMs) * time.Millisecond
+
+	report := &Report{
+		Name:       name,
+		Iterations: iterations,
+		StartedAt:  time.Now(),
+		Results:    []*RequestResult{},
+	}
+
+	// 同一次运行中的请求共享运行时变量，实现 登录 -> 创建 -> 查询 的串联
+	runtime := proxy.NewVariableStore()
+
+	first := true
+	for iter := 1; iter <= iterations && !report.Aborted; iter++ {
+		for _, item := range items {
+			if !first && delay > 0 {
+				time.Sleep(delay)
+			}
+			first = false
+
+			result := execute(item, iter, opts, runtime)
+			report.add(result)
+
+			if !result.Passed && opts.StopOnFailure {
+				report.Aborted = true
+				break
+			}
+		}
+	}
+
+	report.TotalTimeMs = time.Since(report.StartedAt).Milliseconds()
+	return report
+}
+
+func execute(item runItem, iteration int, opts Options, runtime *proxy.VariableStore) *RequestResult {
+	preq := proxy.NewProxyRequest(item.req)
+	preq.EnvironmentID = opts.EnvironmentID
+	preq.Runtime = runtime
+
+	resp := proxy.SendRequest(preq)
+
+	result := &RequestResult{
+		Iteration:  iteration,
+		RequestID:  item.req.ID,
+		Name:       item.req.Name,
+		Folder:     item.folder,
+		Method:     item.req.Method,
+		URL:        item.req.URL,
+		Status:     resp.StatusCode,
+		TimeMs:     resp.TimeMs,
+		Error:      resp.Error,
+		Assertions: resp.Assertions,
+		Extracted:  resp.Extracted,
+		Passed:     resp.Error == "",
+	}
+	for _, a := range resp.Assertions {
+		if !a.Passed {
+			result.Passed = false
+		}
+	}
+	return result
+}
+
+func (r *Report) add(result *RequestResult) {
+	r.Results = append(r.Results, result)
+	r.Total++
+	if result.Passed {
+		r.Passed++
+	} else {
+		r.Failed++
+	}
+	for _, a := range result.Assertions {
+		r.Assertions++
+		if !a.Passed {
+			r.AssertFails++
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"database/sql"
+	"fmt"
+	"go-api-tester/internal/database"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if err := database.Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		database.Close()
+		database.DB = nil
+	})
+}
+
+// newAPI 登录接口每次返回新的令牌，/me 只接受最近一次登录的令牌
+func newAPI(t *testing.T) *httptest.Server {
+	var logins atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/login":
+			fmt.Fprintf(w, `{"token": "t%d"}`, logins.Add(1))
+		case "/me":
+			if r.Header.Get("Authorization") != fmt.Sprintf("Bearer t%d", logins.Load()) {
+				w.WriteHeader(http.StatusUnauthorized)
+			}
+		default:
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func addRequest(t *testing.T, collectionID int64, name, url string, status string, extract bool) {
+	t.Helper()
+	req := &database.Request{
+		CollectionID: collectionID,
+		Name:         name,
+		Method:       "GET",
+		URL:          url,
+		Headers:      []database.KeyValue{{Key: "Authorization", Value: "Bearer {{token}}", Enabled: true}},
+		Body:         database.BodyConfig{Type: "none"},
+		Assertions:   []database.Assertion{{Type: database.AssertStatusEquals, Expected: status, Enabled: true}},
+	}
+	if extract {
+		req.Headers = nil
+		req.Extractors = []database.Extractor{{Type: database.ExtractJSONPath, Expression: "$.token", Variable: "token", Enabled: true}}
+	}
+	if _, err := database.CreateRequest(req); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRunCollectionSharesRuntimeVariables(t *testing.T) {
+	openTestDB(t)
+	srv := newAPI(t)
+	root, _ := database.CreateCollection("api", 0)
+	child, _ := database.CreateCollection("users", root)
+	addRequest(t, root, "login", srv.URL+"/login", "200", true)
+	addRequest(t, child, "me", srv.URL+"/me", "200", false)
+
+	report, err := RunCollection(root, Options{Iterations: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Success() || report.Iterations != 2 || report.Total != 4 || report.Passed != 4 || report.Assertions != 4 {
+		t.Fatalf("report = %+v", report)
+	}
+	var order []string
+	for _, r := range report.Results {
+		order = append(order, fmt.Sprintf("%d:%s:%s", r.Iteration, r.Folder, r.Name))
+	}
+	if fmt.Sprint(order) != "[1:api:login 1:api/users:me 2:api:login 2:api/users:me]" {
+		t.Errorf("order = %v", order)
+	}
+	// 第二轮的 /me 使用第二轮登录提取的令牌
+	if ex := report.Results[2].Extracted; len(ex) != 1 || ex[0].Value != "t2" {
+		t.Errorf("extracted = %+v", ex)
+	}
+}
+
+func TestRunCollectionStopOnFailure(t *testing.T) {
+	openTestDB(t)
+	srv := newAPI(t)
+	root, _ := database.CreateCollection("api", 0)
+	addRequest(t, root, "me", srv.URL+"/me", "200", false) // 未登录，返回 401
+	addRequest(t, root, "login", srv.URL+"/login", "200", true)
+
+	report, err := RunCollection(root, Options{Iterations: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 不设置 StopOnFailure 时继续执行: 只有第一轮的 /me 因变量未解析失败，之后使用上一轮提取的令牌
+	if report.Aborted || report.Total != 6 || report.Failed != 1 || report.Results[0].Error == "" {
+		t.Errorf("without stop: %+v", report)
+	}
+
+	report, err = RunCollection(root, Options{Iterations: 3, StopOnFailure: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !report.Aborted || report.Success() || report.Total != 1 || report.Failed != 1 || report.AssertFails != 1 {
+		t.Errorf("stop on failure: %+v", report)
+	}
+}
+
+func TestRunCollectionNotFound(t *testing.T) {
+	openTestDB(t)
+	if _, err := RunCollection(42, Options{}); err != sql.ErrNoRows {
+		t.Errorf("err = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := RunRequest(42, Options{}); err != sql.ErrNoRows {
+		t.Errorf("err = %v, want sql.ErrNoRows", err)
+	}
+}
@@ -0,0 +1,286 @@
+// Package script 基于 goja (纯 Go 实现的 JavaScript 解释器) 的脚本沙箱，用于请求前与响应后脚本
+//
+// 脚本中可用的对象:
+//
+//	req                      method、url、headers (对象)、body，请求前脚本中修改后生效
+//	res                      status、headers、body、timeMs、json()，仅响应后脚本可用
+//	env.get(name)            读取变量 (按作用域优先级合并后的值)，不存在时返回 undefined
+//	env.set(name, value[, scope])  写入变量，scope: runtime (默认) | environment
+//	test(name, fn)           执行 fn 并记录测试结果，fn 抛出异常即为失败
+//	expect(actual)           断言: toBe、toEqual、toContain、toMatch、toBeTruthy、
+//	                         toBeGreaterThan、toBeLessThan、toHaveProperty，支持 .not 取反
+//	console.log(...)         输出日志
+//
+// 沙箱不提供文件、网络与定时器等能力，执行超时后中断
+package script
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/dop251/goja"
+)
+
+// DefaultTimeout 单个脚本的默认执行时间上限
+const DefaultTimeout = 5 * time.Second
+
+// maxCallStackSize 限制递归深度，防止脚本耗尽栈空间
+const maxCallStackSize = 1024
+
+// interruptGrace 中断后等待脚本退出的时间，超过后放弃等待
+const interruptGrace = 100 * time.Millisecond
+
+// Request 脚本中的 req 对象
+type Request struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    string
+}
+
+// Response 脚本中的 res 对象
+type Response struct {
+	Status  int
+	Headers map[string]string
+	Body    string
+	TimeMs  int64
+}
+
+// Env 脚本读写变量的接口，由调用方实现
+type Env interface {
+	Get(name string) (string, bool)
+	Set(name, value, scope string) error
+}
+
+// Context 脚本执行上下文，Response 为 nil 时表示请求前脚本
+type Context struct {
+	Request  *Request
+	Response *Response
+	Env      Env
+}
+
+// TestResult test() 的执行结果
+type TestResult struct {
+	Name    string
+	Passed  bool
+	Message string
+}
+
+// Result 脚本执行结果，脚本出错时已记录的测试与日志仍会返回
+type Result struct {
+	Tests []TestResult
+	Logs  []string
+}
+
+// prelude 用 JavaScript 实现 test 与 expect，结果通过 __record 回传
+const prelude = `
+var __fmt = function (v) {
+	if (typeof v === "string") return JSON.stringify(v);
+	try { var s = JSON.stringify(v); return s === undefined ? String(v) : s; } catch (e) { return String(v); }
+};
+function Expectation(actual, negate) { this.actual = actual; this.negate = !!negate; }
+Object.defineProperty(Expectation.prototype, "not", {
+	get: function () { return new Expectation(this.actual, !this.negate); }
+});
+Expectation.prototype._check = function (ok, verb, expected, hasExpected) {
+	if (!!ok !== this.negate) return this;
+	var msg = "expected " + __fmt(this.actual) + (this.negate ? " not " : " ") + verb;
+	if (hasExpected) msg += " " + __fmt(expected);
+	throw new Error(msg);
+};
+Expectation.prototype.toBe = function (e) { return this._check(this.actual === e, "to be", e, true); };
+Expectation.prototype.toEqual = function (e) { return this._check(__fmt(this.actual) === __fmt(e), "to equal", e, true); };
+Expectation.prototype.toContain = function (e) {
+	var a = this.actual;
+	return this._check(a != null && typeof a.indexOf === "function" && a.indexOf(e) !== -1, "to contain", e, true);
+};
+Expectation.prototype.toMatch = function (re) { return this._check(new RegExp(re).test(String(this.actual)), "to match", String(re), true); };
+Expectation.prototype.toBeTruthy = function () { return this._check(this.actual, "to be truthy"); };
+Expectation.prototype.toBeGreaterThan = function (n) { return this._check(this.actual > n, "to be greater than", n, true); };
+Expectation.prototype.toBeLessThan = function (n) { return this._check(this.actual < n, "to be less than", n, true); };
+Expectation.prototype.toHaveProperty = function (name) {
+	var a = this.actual;
+	return this._check(a != null && Object.prototype.hasOwnProperty.call(a, name), "to have property", name, true);
+};
+function expect(actual) { return new Expectation(actual, false); }
+function test(name, fn) {
+	try { fn(); __record(String(name), true, ""); }
+	catch (e) { __record(String(name), false, e && e.message !== undefined ? String(e.message) : String(e)); }
+}
+`
+
+// Run 在新的沙箱中执行脚本，请求前脚本对 req 的修改会写回 ctx.Request
+func Run(code string, ctx *Context, timeout time.Duration) (*Result, error) {
+	result := &Result{}
+	if strings.TrimSpace(code) == "" {
+		return result, nil
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+
+	vm := goja.New()
+	vm.SetMaxCallStackSize(maxCallStackSize)
+
+	if err := setup(vm, ctx, result); err != nil {
+		return result, err
+	}
+
+	// Interrupt 只在执行下一条指令前生效，无法打断正则回溯等长时间的原生调用，
+	// 因此脚本在单独的 goroutine 中执行，中断后仍未结束时放弃等待
+	done := make(chan error, 1)
+	go func() {
+		_, err := vm.RunString(code)
+		done <- err
+	}()
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-timer.C:
+		vm.Interrupt("timeout")
+		select {
+		case err = <-done:
+		case <-time.After(interruptGrace):
+			// 脚本仍在运行并可能写入 result，返回空结果
+			return &Result{}, fmt.Errorf("脚本执行超时 (%v)", timeout)
+		}
+	}
+	if err != nil {
+		var interrupted *goja.InterruptedError
+		if errors.As(err, &interrupted) {
+			return result, fmt.Errorf("脚本执行超时 (%v)", timeout)
+		}
+		var ex *goja.Exception
+		if errors.As(err, &ex) {
+			return result, fmt.Errorf("%s", ex.Value().String())
+		}
+		return result, err
+	}
+
+	if ctx.Response == nil && ctx.Request != nil {
+		return result, readRequest(vm, ctx.Request)
+	}
+	return result, nil
+}
+
+func setup(vm *goja.Runtime, ctx *Context, result *Result) error {
+	vm.Set("__record", func(name string, passed bool, message string) {
+		result.Tests = append(result.Tests, TestResult{Name: name, Passed: passed, Message: message})
+	})
+
+	console := vm.NewObject()
+	console.Set("log", func(call goja.FunctionCall) goja.Value {
+		parts := make([]string, len(call.Arguments))
+		for i, arg := range call.Arguments {
+			parts[i] = formatValue(arg)
+		}
+		result.Logs = append(result.Logs, strings.Join(parts, " "))
+		return goja.Undefined()
+	})
+	vm.Set("console", console)
+
+	env := vm.NewObject()
+	env.Set("get", func(name string) goja.Value {
+		if ctx.Env == nil {
+			return goja.Undefined()
+		}
+		if v, ok := ctx.Env.Get(name); ok {
+			return vm.ToValue(v)
+		}
+		return goja.Undefined()
+	})
+	env.Set("set", func(name string, value, scope goja.Value) {
+		if ctx.Env == nil {
+			panic(vm.NewGoError(fmt.Errorf("env 不可用")))
+		}
+		if err := ctx.Env.Set(name, formatValue(value), valueString(scope)); err != nil {
+			panic(vm.NewGoError(err))
+		}
+	})
+	vm.Set("env", env)
+
+	if ctx.Request != nil {
+		req := vm.NewObject()
+		req.Set("method", ctx.Request.Method)
+		req.Set("url", ctx.Request.URL)
+		req.Set("headers", stringObject(vm, ctx.Request.Headers))
+		req.Set("body", ctx.Request.Body)
+		vm.Set("req", req)
+	}
+
+	if ctx.Response != nil {
+		r := ctx.Response
+		res := vm.NewObject()
+		res.Set("status", r.Status)
+		res.Set("headers", stringObject(vm, r.Headers))
+		res.Set("body", r.Body)
+		res.Set("timeMs", r.TimeMs)
+		res.Set("json", func() goja.Value {
+			var v interface{}
+			if err := json.Unmarshal([]byte(r.Body), &v); err != nil {
+				panic(vm.NewGoError(fmt.Errorf("响应不是有效的 JSON: %v", err)))
+			}
+			return vm.ToValue(v)
+		})
+		vm.Set("res", res)
+	}
+
+	_, err := vm.RunString(prelude)
+	return err
+}
+
+// readRequest 将脚本对 req 的修改写回
+func readRequest(vm *goja.Runtime, r *Request) error {
+	obj := vm.Get("req")
+	if obj == nil || goja.IsUndefined(obj) || goja.IsNull(obj) {
+		return fmt.Errorf("req 不能被删除")
+	}
+	req := obj.ToObject(vm)
+	r.Method = strings.ToUpper(valueString(req.Get("method")))
+	r.URL = valueString(req.Get("url"))
+	r.Body = valueString(req.Get("body"))
+
+	r.Headers = make(map[string]string)
+	if h := req.Get("headers"); h != nil && !goja.IsUndefined(h) && !goja.IsNull(h) {
+		headers := h.ToObject(vm)
+		for _, k := range headers.Keys() {
+			r.Headers[k] = formatValue(headers.Get(k))
+		}
+	}
+	return nil
+}
+
+func stringObject(vm *goja.Runtime, m map[string]string) *goja.Object {
+	obj := vm.NewObject()
+	for k, v := range m {
+		obj.Set(k, v)
+	}
+	return obj
+}
+
+func valueString(v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) || goja.IsNull(v) {
+		return ""
+	}
+	return v.String()
+}
+
+// formatValue 字符串原样输出，其他值输出为 JSON
+func formatValue(v goja.Value) string {
+	if v == nil || goja.IsUndefined(v) {
+		return "undefined"
+	}
+	if s, ok := v.Export().(string); ok {
+		return s
+	}
+	if b, err := json.Marshal(v.Export()); err == nil {
+		return string(b)
+	}
+	return v.String()
+}
@@ -0,0 +1,103 @@
+package script
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+type mapEnv map[string]string
+
+func (e mapEnv) Get(name string) (string, bool) {
+	v, ok := e[name]
+	return v, ok
+}
+
+func (e mapEnv) Set(name, value, scope string) error {
+	e[name] = value
+	return nil
+}
+
+func TestRunPreRequest(t *testing.T) {
+	env := mapEnv{"token": "abc"}
+	req := &Request{Method: "get", URL: "http://x", Headers: map[string]string{"A": "1"}}
+	res, err := Run(`
+		req.method = "post";
+		req.headers["Authorization"] = "Bearer " + env.get("token");
+		req.body = JSON.stringify({n: 1});
+		env.set("seen", {ok: true});
+		console.log("sent", 1);
+	`, &Context{Request: req, Env: env}, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if req.Method != "POST" || req.Headers["Authorization"] != "Bearer abc" || req.Headers["A"] != "1" || req.Body != `{"n":1}` {
+		t.Errorf("request = %+v", req)
+	}
+	if env["seen"] != `{"ok":true}` {
+		t.Errorf("env = %v", env)
+	}
+	if len(res.Logs) != 1 || res.Logs[0] != "sent 1" {
+		t.Errorf("logs = %q", res.Logs)
+	}
+}
+
+func TestRunTests(t *testing.T) {
+	ctx := &Context{Response: &Response{Status: 201, Body: `{"id": 7, "tags": ["a"]}`}}
+	res, err := Run(`
+		var body = res.json();
+		test("status", function () { expect(res.status).toBe(201); });
+		test("id", function () { expect(body).toHaveProperty("id"); expect(body.tags).toContain("a"); });
+		test("negated", function () { expect(body.id).not.toBe(7); });
+	`, ctx, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []TestResult{
+		{Name: "status", Passed: true},
+		{Name: "id", Passed: true},
+		{Name: "negated", Message: "expected 7 not to be 7"},
+	}
+	if len(res.Tests) != len(want) {
+		t.Fatalf("tests = %+v", res.Tests)
+	}
+	for i, w := range want {
+		if res.Tests[i] != w {
+			t.Errorf("test %d = %+v, want %+v", i, res.Tests[i], w)
+		}
+	}
+}
+
+func TestRunError(t *testing.T) {
+	res, err := Run(`console.log("before"); throw new Error("boom");`, &Context{}, 0)
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("err = %v", err)
+	}
+	if len(res.Logs) != 1 {
+		t.Errorf("logs before error lost: %q", res.Logs)
+	}
+}
+
+func TestRunTimeout(t *testing.T) {
+	scripts := map[string]string{
+		"loop": `while (true) {}`,
+		// 前瞻断言使 goja 使用回溯引擎 regexp2，匹配期间 Interrupt 不生效
+		"regex": `/(a+)+(?=c)/.test("` + strings.Repeat("a", 40) + `b")`,
+	}
+	for name, code := range scripts {
+		start := time.Now()
+		_, err := Run(code, &Context{}, 100*time.Millisecond)
+		if err == nil || !strings.Contains(err.Error(), "超时") {
+			t.Errorf("%s: err = %v", name, err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("%s: returned after %v", name, elapsed)
+		}
+	}
+
+	// 可由 RE2 处理的正则在线性时间内完成
+	res, err := Run(`console.log(/(a+)+$/.test("`+strings.Repeat("a", 40)+`b"))`, &Context{}, 100*time.Millisecond)
+	if err != nil || len(res.Logs) != 1 || res.Logs[0] != "false" {
+		t.Errorf("linear regex = %+v, %v", res, err)
+	}
+}
@@ -56,11 +56,15 @@ func (s *Server) routes() {
 
 	// 代理服务
 	s.Mux.HandleFunc("POST /api/proxy/send", proxy.HandleSend)
+	s.Mux.HandleFunc("GET /api/runtime-variables", proxy.HandleGetRuntimeVariables)
+	s.Mux.HandleFunc("DELETE /api/runtime-variables", proxy.HandleClearRuntimeVariables)
 
 	// 分组管理
 	s.Mux.HandleFunc("GET /api/collections", api.HandleGetCollections)
 	s.Mux.HandleFunc("POST /api/collections", api.HandleCreateCollection)
+	s.Mux.HandleFunc("PUT /api/collections/{id}", api.HandleUpdateCollection)
 	s.Mux.HandleFunc("DELETE /api/collections/{id}", api.HandleDeleteCollection)
+	s.Mux.HandleFunc("POST /api/collections/{id}/run", api.HandleRunCollection)
 
 	// 请求管理
 	s.Mux.HandleFunc("GET /api/requests", api.HandleListRequests)
@@ -68,6 +72,7 @@ func (s *Server) routes() {
 	s.Mux.HandleFunc("GET /api/requests/{id}", api.HandleGetRequest)
 	s.Mux.HandleFunc("PUT /api/requests/{id}", api.HandleUpdateRequest)
 	s.Mux.HandleFunc("DELETE /api/requests/{id}", api.HandleDeleteRequest)
+	s.Mux.HandleFunc("POST /api/requests/{id}/run", api.HandleRunRequest)
 
 	// Mock 规则管理
 	s.Mux.HandleFunc("GET /api/mocks", api.HandleListMockRules)
@@ -75,6 +80,63 @@ func (s *Server) routes() {
 	s.Mux.HandleFunc("PUT /api/mocks/{id}", api.HandleUpdateMockRule)
 	s.Mux.HandleFunc("DELETE /api/mocks/{id}", api.HandleDeleteMockRule)
 
+	// Mock 场景 (状态机) 的状态查看与重置
+	s.Mux.HandleFunc("GET /api/mocks/scenarios", api.HandleListMockScenarios)
+	s.Mux.HandleFunc("DELETE /api/mocks/scenarios", api.HandleResetMockScenarios)
+	s.Mux.HandleFunc("GET /api/mocks/scenarios/{name}", api.HandleGetMockScenario)
+	s.Mux.HandleFunc("PUT /api/mocks/scenarios/{name}", api.HandleSetMockScenarioState)
+	s.Mux.HandleFunc("DELETE /api/mocks/scenarios/{name}", api.HandleResetMockScenario)
+
+	// 环境管理
+	s.Mux.HandleFunc("GET /api/environments", api.HandleListEnvironments)
+	s.Mux.HandleFunc("POST /api/environments", api.HandleCreateEnvironment)
+	s.Mux.HandleFunc("GET /api/environments/{id}", api.HandleGetEnvironment)
+	s.Mux.HandleFunc("PUT /api/environments/{id}", api.HandleUpdateEnvironment)
+	s.Mux.HandleFunc("DELETE /api/environments/{id}", api.HandleDeleteEnvironment)
+	s.Mux.HandleFunc("POST /api/environments/{id}/activate", api.HandleActivateEnvironment)
+
+	// Cookie 管理
+	s.Mux.HandleFunc("GET /api/cookies", api.HandleListCookies)
+	s.Mux.HandleFunc("POST /api/cookies", api.HandleCreateCookie)
+	s.Mux.HandleFunc("PUT /api/cookies/{id}", api.HandleUpdateCookie)
+	s.Mux.HandleFunc("DELETE /api/cookies/{id}", api.HandleDeleteCookie)
+	s.Mux.HandleFunc("DELETE /api/cookies", api.HandleClearCookies)
+
+	// 全局变量
+	s.Mux.HandleFunc("GET /api/globals", api.HandleGetGlobals)
+	s.Mux.HandleFunc("PUT /api/globals", api.HandleSaveGlobals)
+
+	// GraphQL schema 内省与查询校验
+	s.Mux.HandleFunc("POST /api/graphql/introspect", api.HandleIntrospectGraphQL)
+	s.Mux.HandleFunc("POST /api/graphql/validate", api.HandleValidateGraphQL)
+	s.Mux.HandleFunc("GET /api/graphql/schema", api.HandleGetGraphQLSchema)
+	s.Mux.HandleFunc("DELETE /api/graphql/schema", api.HandleDeleteGraphQLSchema)
+
+	// 附件 (form-data 文件字段与 binary 请求体引用)
+	s.Mux.HandleFunc("GET /api/attachments", api.HandleListAttachments)
+	s.Mux.HandleFunc("POST /api/attachments", api.HandleUploadAttachment)
+	s.Mux.HandleFunc("GET /api/attachments/{id}", api.HandleDownloadAttachment)
+	s.Mux.HandleFunc("DELETE /api/attachments/{id}", api.HandleDeleteAttachment)
+
+	// 客户端证书 (mTLS) 与自定义 CA
+	s.Mux.HandleFunc("GET /api/certificates", api.HandleListCertificates)
+	s.Mux.HandleFunc("POST /api/certificates", api.HandleCreateCertificate)
+	s.Mux.HandleFunc("PUT /api/certificates/{id}", api.HandleUpdateCertificate)
+	s.Mux.HandleFunc("DELETE /api/certificates/{id}", api.HandleDeleteCertificate)
+
+	// OAuth 2.0 令牌缓存与授权码 (PKCE) 流程
+	s.Mux.HandleFunc("GET /api/oauth2/tokens", api.HandleListOAuth2Tokens)
+	s.Mux.HandleFunc("POST /api/oauth2/tokens", api.HandleFetchOAuth2Token)
+	s.Mux.HandleFunc("DELETE /api/oauth2/tokens/{id}", api.HandleDeleteOAuth2Token)
+	s.Mux.HandleFunc("POST /api/oauth2/authorize", api.HandleAuthorizeOAuth2)
+	s.Mux.HandleFunc("GET /api/oauth2/callback", api.HandleOAuth2Callback)
+
+	// 全局客户端设置
+	s.Mux.HandleFunc("GET /api/settings/client", api.HandleGetClientSettings)
+	s.Mux.HandleFunc("PUT /api/settings/client", api.HandleSaveClientSettings)
+	s.Mux.HandleFunc("GET /api/settings/proxy", api.HandleGetProxySettings)
+	s.Mux.HandleFunc("PUT /api/settings/proxy", api.HandleSaveProxySettings)
+
 	// 数据导入导出
 	s.Mux.HandleFunc("GET /api/export", api.HandleExportData)
 	s.Mux.HandleFunc("POST /api/import", api.HandleImportData)
@@ -83,6 +145,8 @@ func (s *Server) routes() {
 	s.Mux.HandleFunc("GET /api/history", api.HandleGetHistory)
 	s.Mux.HandleFunc("POST /api/history", api.HandleCreateHistory)
 	s.Mux.HandleFunc("DELETE /api/history", api.HandleDeleteHistory)
+	s.Mux.HandleFunc("GET /api/history/{id}", api.HandleGetHistoryItem)
+	s.Mux.HandleFunc("GET /api/history/{id}/response", api.HandleGetHistoryResponse)
 
 	// 动态 Mock 服务 (匹配所有 /mock/ 开头的请求)
 	s.Mux.HandleFunc("/mock/", mock.HandleMockRequest)
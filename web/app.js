@@ -275,13 +275,14 @@ function renderHistoryList(){
             // 添加单条删除按钮 (x)
             el.innerHTML=`
                 <span class="req-method req-${i.method}" style="font-size:9px;width:30px;">${i.method}</span>
-                <span class="tree-label" style="font-size:12px;flex:1;overflow:hidden;text-overflow:ellipsis;">${escapeHtml(i.url)}</span>
+                <span class="tree-label" style="font-size:12px;flex:1;overflow:hidden;text-overflow:ellipsis;" title="${escapeHtml(i.resolved_url || i.url)}">${escapeHtml(i.url)}</span>
                 <span class="history-time" style="font-size:10px;color:#ccc;margin-right:5px;">${t}</span>
                 <span title="Delete Item" style="cursor:pointer;color:#999;font-weight:bold;padding:0 4px;" onmouseover="this.style.color='red'" onmouseout="this.style.color='#999'">×</span>
             `;
             el.onclick=()=>{
                 const d=i.request; d.id=0; d.name=i.url;
                 openNewTab(d, { type: 'history', id: i.id });
+                loadHistoryResponse(i.id);
             };
             // 绑定单条删除事件
             el.querySelector('span:last-child').onclick = (e) => { e.stopPropagation(); deleteHistoryItem(i.id); };
@@ -291,6 +292,22 @@ function renderHistoryList(){
 }
 
 // [新增] 历史记录操作函数
+// 加载历史记录保存的响应到当前标签
+async function loadHistoryResponse(id) {
+    try {
+        const res = await fetch(`/api/history/${id}/response`);
+        if (!res.ok) return;
+        const data = await res.json();
+        store.response.rawBody = data.body;
+        store.response.isBinary = data.is_binary;
+        store.response.headers = data.headers || {};
+        store.response.status = data.status;
+        store.response.time_ms = data.time_ms;
+        store.response.error = data.error;
+        renderResponseUI();
+    } catch (e) { console.error(e); }
+}
+
 async function deleteHistoryItem(id) {
     if(!confirm("Delete this history item?")) return;
     try {
@@ -398,18 +415,19 @@ async function sendRequest() {
         body_type: store.current.body.type,
         raw_body: store.current.body.raw_content,
         form_data: store.current.body.form_data,
-        url_encoded: store.current.body.url_encoded
+        url_encoded: store.current.body.url_encoded,
+        binary_path: store.current.body.binary_path || '',
+        graphql_query: store.current.body.graphql_query || '',
+        graphql_vars: store.current.body.graphql_vars || '',
+        graphql_operation: store.current.body.graphql_operation || '',
+        collection_id: store.current.collection_id || 0,
+        variables: store.current.variables || [],
+        assertions: store.current.assertions || [],
+        extractors: store.current.extractors || [],
+        scripts: store.current.scripts || {},
+        settings: store.current.settings || {}
     };
 
-    // 异步保存历史
-    const historyPayload = JSON.parse(JSON.stringify(store.current));
-    fetch('/api/history', { method: 'POST', headers: {'Content-Type': 'application/json'}, body: JSON.stringify(historyPayload) })
-        .then(async () => {
-            const hRes = await fetch('/api/history');
-            store.history = await hRes.json();
-            if (store.mode === 'history') renderHistoryList();
-        }).catch(console.error);
-
     try {
         const res = await fetch('/api/proxy/send', {
             method: 'POST',
@@ -429,6 +447,13 @@ async function sendRequest() {
         // [修复] 统一使用 renderResponseUI 刷新界面
         renderResponseUI();
 
+        // 历史记录由服务端在发送后保存，这里只刷新列表
+        fetch('/api/history')
+            .then(async (hRes) => {
+                store.history = (await hRes.json()) || [];
+                if (store.mode === 'history') renderHistoryList();
+            }).catch(console.error);
+
     } catch (e) {
         store.response.error = "Frontend Error: " + e.message;
         renderResponseUI();
@@ -686,8 +711,8 @@ function downloadJSON(d,n){const b=new Blob([JSON.stringify(d,null,2)],{type:'ap
 function renderMockList() { const c=document.getElementById('sidebar-list'); c.innerHTML=''; if(!store.mocks.length){c.innerHTML='<div style="padding:20px;color:#999;text-align:center">No mocks</div>';return;} store.mocks.forEach(m=>{const el=document.createElement('div'); el.className=`mock-item ${store.currentMock.id===m.id?'active':''}`; el.innerHTML=`<div class="mock-status ${m.is_active?'on':''}"></div><span class="req-method req-${m.method}">${m.method}</span><span style="flex:1;overflow:hidden;text-overflow:ellipsis;">${escapeHtml(m.path_pattern)}</span>`; el.onclick=()=>loadMock(m); c.appendChild(el);}); }
 function loadMock(m){store.currentMock=JSON.parse(JSON.stringify(m));if(!store.currentMock.response_headers)store.currentMock.response_headers={};renderMockForm();renderMockList();}
 window.resetMockForm=()=>{store.currentMock={id:0,path_pattern:"",method:"GET",status_code:200,response_body:"",response_headers:{},is_active:true};renderMockForm();renderMockList();}
-function renderMockForm(){ const m=store.currentMock; document.getElementById('mock-path').value=m.path_pattern; document.getElementById('mock-method').value=m.method; document.getElementById('mock-status').value=m.status_code; document.getElementById('mock-body').value=m.response_body||''; document.getElementById('mock-active').checked=m.is_active; const h=Object.entries(m.response_headers||{}).map(([k,v])=>({key:k,value:v,enabled:true})); ensureEmptyRow(h); renderKVTable('mock-headers-container',h,()=>{}); document.getElementById('btn-delete-mock').style.display=m.id>0?'inline-block':'none'; updateMockUrlPreview(); }
-window.saveMockRule=async()=>{ const m=store.currentMock; m.path_pattern=document.getElementById('mock-path').value; m.method=document.getElementById('mock-method').value; m.status_code=parseInt(document.getElementById('mock-status').value)||200; m.response_body=document.getElementById('mock-body').value; m.is_active=document.getElementById('mock-active').checked; m.response_headers={}; document.querySelectorAll('#mock-headers-container .kv-row').forEach(r=>{const k=r.querySelector('.key').value; const v=r.querySelector('.val').value; if(k)m.response_headers[k]=v;}); const url=m.id?`/api/mocks/${m.id}`:'/api/mocks'; const meth=m.id?'PUT':'POST'; try{const r=await fetch(url,{method:meth,headers:{'Content-Type':'application/json'},body:JSON.stringify(m)}); if(r.ok){if(meth==='POST')m.id=(await r.json()).id; await loadData(); renderMockList(); alert("Saved");}else alert("Failed");}catch(e){alert("Error");} };
+function renderMockForm(){ const m=store.currentMock; document.getElementById('mock-path').value=m.path_pattern; document.getElementById('mock-method').value=m.method; document.getElementById('mock-status').value=m.status_code; document.getElementById('mock-body').value=m.response_body||''; document.getElementById('mock-active').checked=m.is_active; document.getElementById('mock-template').checked=!!m.template; const h=Object.entries(m.response_headers||{}).map(([k,v])=>({key:k,value:v,enabled:true})); ensureEmptyRow(h); renderKVTable('mock-headers-container',h,()=>{}); document.getElementById('btn-delete-mock').style.display=m.id>0?'inline-block':'none'; updateMockUrlPreview(); }
+window.saveMockRule=async()=>{ const m=store.currentMock; m.path_pattern=document.getElementById('mock-path').value; m.method=document.getElementById('mock-method').value; m.status_code=parseInt(document.getElementById('mock-status').value)||200; m.response_body=document.getElementById('mock-body').value; m.is_active=document.getElementById('mock-active').checked; m.template=document.getElementById('mock-template').checked; m.response_headers={}; document.querySelectorAll('#mock-headers-container .kv-row').forEach(r=>{const k=r.querySelector('.key').value; const v=r.querySelector('.val').value; if(k)m.response_headers[k]=v;}); const url=m.id?`/api/mocks/${m.id}`:'/api/mocks'; const meth=m.id?'PUT':'POST'; try{const r=await fetch(url,{method:meth,headers:{'Content-Type':'application/json'},body:JSON.stringify(m)}); if(r.ok){if(meth==='POST')m.id=(await r.json()).id; await loadData(); renderMockList(); alert("Saved");}else alert("Failed");}catch(e){alert("Error");} };
 window.deleteMockRule=async()=>{if(!confirm("Delete?"))return; await fetch(`/api/mocks/${store.currentMock.id}`,{method:'DELETE'}); await loadData(); resetMockForm();};
 window.updateMockUrlPreview=()=>{const p=document.getElementById('mock-path').value; const u=`${location.protocol}//${location.hostname}:${location.port}/mock/${p.startsWith('/')?p.substring(1):p}`; document.querySelector('#mock-url-preview span').innerText=u;}
 window.copyMockUrl=()=>{navigator.clipboard.writeText(document.querySelector('#mock-url-preview span').innerText);alert("Copied");};